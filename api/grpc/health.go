@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/blackwatch66/user-microservice/api/grpc/proto"
+	"github.com/blackwatch66/user-microservice/internal/health"
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"google.golang.org/grpc"
+	grpcHealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var healthLog = logger.For("health")
+
+// HealthReporter 根据依赖检查结果周期性更新标准 grpc.health.v1 服务状态
+type HealthReporter struct {
+	server   *grpcHealth.Server
+	checker  *health.Checker
+	interval time.Duration
+}
+
+// NewHealthReporter 创建一个新的 HealthReporter，初始状态为 NOT_SERVING
+func NewHealthReporter(checker *health.Checker, interval time.Duration) *HealthReporter {
+	r := &HealthReporter{
+		server:   grpcHealth.NewServer(),
+		checker:  checker,
+		interval: interval,
+	}
+	r.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	return r
+}
+
+// Register 将健康检查服务注册到 gRPC Server
+func (r *HealthReporter) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, r.server)
+}
+
+// Run 立即执行一次检查，之后按 interval 周期执行，直到 ctx 结束
+func (r *HealthReporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.update(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown 将所有服务标记为 NOT_SERVING，并忽略之后的状态更新
+func (r *HealthReporter) Shutdown() {
+	r.server.Shutdown()
+}
+
+// update 执行依赖检查并设置服务状态，可选依赖（Redis）不可用时仍为 SERVING
+func (r *HealthReporter) update(ctx context.Context) {
+	results := r.checker.Run(ctx)
+	for _, result := range results {
+		if result.Err != nil {
+			healthLog.WarnContext(ctx, "Dependency is unhealthy", "dependency", result.Name, "optional", result.Optional, "error", result.Err)
+		}
+	}
+
+	if health.AllHealthy(results) {
+		r.setStatus(healthpb.HealthCheckResponse_SERVING)
+		return
+	}
+	r.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// setStatus 同时设置整体状态（空服务名）和 UserService 的状态
+func (r *HealthReporter) setStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	r.server.SetServingStatus("", status)
+	r.server.SetServingStatus(pb.UserService_ServiceDesc.ServiceName, status)
+}
@@ -0,0 +1,138 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/blackwatch66/user-microservice/internal/idempotency"
+	"github.com/blackwatch66/user-microservice/internal/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	// IdempotencyKeyMetadata 与 HTTP 的 Idempotency-Key 请求头等价的 metadata 键
+	IdempotencyKeyMetadata = "idempotency-key"
+	// IdempotentReplayedMetadata 响应是首次调用结果的重放时在响应 header 中设置为 true
+	IdempotentReplayedMetadata = "idempotent-replayed"
+)
+
+// retryableCodes 可能是暂时性故障的状态码，结果不保存，允许客户端用同一个键重试
+var retryableCodes = map[codes.Code]bool{
+	codes.Canceled:          true,
+	codes.Unknown:           true,
+	codes.DeadlineExceeded:  true,
+	codes.ResourceExhausted: true,
+	codes.Aborted:           true,
+	codes.Internal:          true,
+	codes.Unavailable:       true,
+}
+
+// IdempotencyUnaryInterceptor 对 methods 中的方法启用幂等键：携带 idempotency-key metadata 的调用只执行一次，
+// 相同请求的重试重放首次的响应或错误；键相同但请求不同返回 InvalidArgument，首次调用尚未完成时返回 Aborted
+func IdempotencyUnaryInterceptor(guard *idempotency.Guard, methods ...string) grpc.UnaryServerInterceptor {
+	enabled := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		enabled[method] = true
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		key := idempotencyKeyFromMetadata(ctx)
+		msg, isProto := req.(proto.Message)
+		if key == "" || !enabled[info.FullMethod] || !isProto {
+			return handler(ctx, req)
+		}
+
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode request: %v", err)
+		}
+		attempt, err := guard.Begin(ctx, idempotencyScope(info.FullMethod, msg), key, idempotency.Fingerprint([]byte(info.FullMethod), body))
+		switch {
+		case errors.Is(err, idempotency.ErrInvalidKey):
+			return nil, status.Error(codes.InvalidArgument, "idempotency-key must be 1 to 255 printable ASCII characters")
+		case errors.Is(err, idempotency.ErrMismatch):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, idempotency.ErrInProgress):
+			return nil, status.Error(codes.Aborted, err.Error())
+		case errors.Is(err, idempotency.ErrUnavailable):
+			return nil, status.Error(codes.Unavailable, "service temporarily unavailable")
+		case err != nil:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		if attempt.Replay != nil {
+			return replayResponse(ctx, attempt.Replay)
+		}
+
+		// handler panic 时释放键，否则重试会在键过期前一直得到 Aborted
+		defer attempt.Release(ctx)
+		resp, err := handler(ctx, req)
+		if err != nil {
+			st := status.Convert(err)
+			if !retryableCodes[st.Code()] {
+				attempt.Complete(ctx, &repository.IdempotencyRecord{Status: int(st.Code()), Message: st.Message()})
+			}
+			return resp, err
+		}
+		if respMsg, ok := resp.(proto.Message); ok {
+			data, marshalErr := proto.Marshal(respMsg)
+			if marshalErr == nil {
+				attempt.Complete(ctx, &repository.IdempotencyRecord{
+					Status:      int(codes.OK),
+					ContentType: string(proto.MessageName(respMsg)),
+					Body:        data,
+				})
+			}
+		}
+		return resp, nil
+	}
+}
+
+// idempotencyScope 按方法和请求中的 user_id 隔离幂等键，不同用户或不同方法使用同一个键不会冲突。
+// gRPC 调用方没有用户身份，用户取自请求本身；没有 user_id 的方法（如 CreateUser）按方法共享一个作用域
+func idempotencyScope(method string, msg proto.Message) string {
+	scope := "grpc:" + method
+	m := msg.ProtoReflect()
+	field := m.Descriptor().Fields().ByName("user_id")
+	if field != nil && (field.Kind() == protoreflect.Uint64Kind || field.Kind() == protoreflect.Uint32Kind) {
+		if userID := m.Get(field).Uint(); userID != 0 {
+			scope += ":user:" + strconv.FormatUint(userID, 10)
+		}
+	}
+	return scope
+}
+
+// idempotencyKeyFromMetadata 读取调用方设置的幂等键
+func idempotencyKeyFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(IdempotencyKeyMetadata); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// replayResponse 根据保存的记录重建首次调用的响应或错误
+func replayResponse(ctx context.Context, record *repository.IdempotencyRecord) (interface{}, error) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(IdempotentReplayedMetadata, "true"))
+	if code := codes.Code(record.Status); code != codes.OK {
+		return nil, status.Error(code, record.Message)
+	}
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(record.ContentType))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot replay response of type %s: %v", record.ContentType, err)
+	}
+	resp := messageType.New().Interface()
+	if err := proto.Unmarshal(record.Body, resp); err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot replay response: %v", err)
+	}
+	return resp, nil
+}
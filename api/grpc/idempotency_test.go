@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/blackwatch66/user-microservice/api/grpc/proto"
+	"github.com/blackwatch66/user-microservice/internal/idempotency"
+	"github.com/blackwatch66/user-microservice/internal/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	deleteAddressMethod = "/user.UserService/DeleteAddress"
+	createUserMethod    = "/user.UserService/CreateUser"
+)
+
+func TestIdempotencyScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		req    proto.Message
+		want   string
+	}{
+		{"request with a user", deleteAddressMethod, &pb.DeleteAddressRequest{UserId: 7, AddressId: 3}, "grpc:" + deleteAddressMethod + ":user:7"},
+		{"request without a user", createUserMethod, &pb.CreateUserRequest{Email: "ada@example.com"}, "grpc:" + createUserMethod},
+		{"user ID not set", deleteAddressMethod, &pb.DeleteAddressRequest{AddressId: 3}, "grpc:" + deleteAddressMethod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idempotencyScope(tt.method, tt.req); got != tt.want {
+				t.Errorf("idempotencyScope() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdempotencyUnaryInterceptor(t *testing.T) {
+	first := &pb.DeleteAddressRequest{UserId: 7, AddressId: 3}
+	tests := []struct {
+		name       string
+		inProgress bool // the first call has not completed
+		method     string
+		retry      proto.Message
+		wantCode   codes.Code
+		wantCalls  int
+	}{
+		{"identical retry replays", false, deleteAddressMethod, first, codes.OK, 1},
+		{"different request of the same user", false, deleteAddressMethod, &pb.DeleteAddressRequest{UserId: 7, AddressId: 4}, codes.InvalidArgument, 1},
+		{"same key from another user", false, deleteAddressMethod, &pb.DeleteAddressRequest{UserId: 8, AddressId: 3}, codes.OK, 2},
+		{"same key on another method", false, createUserMethod, &pb.CreateUserRequest{Email: "ada@example.com"}, codes.OK, 2},
+		{"retry while in progress", true, deleteAddressMethod, first, codes.Aborted, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := idempotency.NewGuard(repository.NewMemoryIdempotencyStore(), time.Hour, false)
+			interceptor := IdempotencyUnaryInterceptor(guard, deleteAddressMethod, createUserMethod)
+			calls := 0
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls++
+				return &pb.DeleteAddressResponse{}, nil
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyMetadata, "key-1"))
+			call := func(method string, req proto.Message) error {
+				_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+				return err
+			}
+
+			if tt.inProgress {
+				body, _ := proto.MarshalOptions{Deterministic: true}.Marshal(first)
+				fingerprint := idempotency.Fingerprint([]byte(deleteAddressMethod), body)
+				if _, err := guard.Begin(ctx, idempotencyScope(deleteAddressMethod, first), "key-1", fingerprint); err != nil {
+					t.Fatal(err)
+				}
+			} else if err := call(deleteAddressMethod, first); err != nil {
+				t.Fatal(err)
+			}
+
+			if code := status.Code(call(tt.method, tt.retry)); code != tt.wantCode {
+				t.Errorf("retry = %s, want %s", code, tt.wantCode)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
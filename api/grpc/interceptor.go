@@ -0,0 +1,110 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/audit"
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var grpcLog = logger.For("grpc")
+
+// MetricsUnaryInterceptor 记录每个 gRPC 方法的请求数与延迟
+func MetricsUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeGRPC(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// MetricsStreamInterceptor 记录每个流式 gRPC 方法的请求数与持续时间
+func MetricsStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observeGRPC(info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observeGRPC(method string, start time.Time, err error) {
+	metrics.GRPCRequestsTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+	metrics.GRPCRequestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// LoggingUnaryInterceptor 从 metadata 读取或生成请求 ID，并以结构化日志记录每次调用
+func LoggingUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx = withRequestID(ctx)
+		ctx = withClientInfo(ctx)
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		switch code {
+		case codes.OK:
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+			level = slog.LevelError
+		default:
+			level = slog.LevelWarn
+		}
+		attrs := []any{
+			"method", info.FullMethod,
+			"code", code.String(),
+			"latency_ms", time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		grpcLog.Log(ctx, level, "gRPC request handled", attrs...)
+		return resp, err
+	}
+}
+
+// withRequestID 将调用方传入的请求 ID（或新生成的 ID）写入上下文与响应 header
+func withRequestID(ctx context.Context) context.Context {
+	key := strings.ToLower(logger.RequestIDHeader)
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(key); len(values) > 0 && len(values[0]) <= 128 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = logger.NewRequestID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(key, requestID))
+	return logger.WithRequestID(ctx, requestID)
+}
+
+// withClientInfo 将调用方地址与 user-agent 写入上下文，供审计日志使用。
+// gRPC 接口仅供受信任的内部服务调用且没有用户认证，审计日志中的操作者记为 system
+func withClientInfo(ctx context.Context) context.Context {
+	var ip, userAgent string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("user-agent"); len(values) > 0 {
+			userAgent = values[0]
+		}
+	}
+	return audit.WithSystem(audit.WithClient(ctx, ip, userAgent))
+}
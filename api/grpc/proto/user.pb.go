@@ -9,6 +9,7 @@ package proto
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,6 +22,56 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// 地址的格式化方式
+type AddressFormat int32
+
+const (
+	AddressFormat_ADDRESS_FORMAT_UNSPECIFIED AddressFormat = 0 // 不格式化
+	AddressFormat_ADDRESS_FORMAT_MULTI_LINE  AddressFormat = 1 // 多行，以 \n 分隔，用于打印标签和信封
+	AddressFormat_ADDRESS_FORMAT_SINGLE_LINE AddressFormat = 2 // 单行，以 ", " 分隔，用于邮件和列表
+)
+
+// Enum value maps for AddressFormat.
+var (
+	AddressFormat_name = map[int32]string{
+		0: "ADDRESS_FORMAT_UNSPECIFIED",
+		1: "ADDRESS_FORMAT_MULTI_LINE",
+		2: "ADDRESS_FORMAT_SINGLE_LINE",
+	}
+	AddressFormat_value = map[string]int32{
+		"ADDRESS_FORMAT_UNSPECIFIED": 0,
+		"ADDRESS_FORMAT_MULTI_LINE":  1,
+		"ADDRESS_FORMAT_SINGLE_LINE": 2,
+	}
+)
+
+func (x AddressFormat) Enum() *AddressFormat {
+	p := new(AddressFormat)
+	*p = x
+	return p
+}
+
+func (x AddressFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AddressFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_grpc_proto_user_proto_enumTypes[0].Descriptor()
+}
+
+func (AddressFormat) Type() protoreflect.EnumType {
+	return &file_api_grpc_proto_user_proto_enumTypes[0]
+}
+
+func (x AddressFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AddressFormat.Descriptor instead.
+func (AddressFormat) EnumDescriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{0}
+}
+
 // 创建用户请求
 type CreateUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -79,6 +130,7 @@ type CreateUserResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Version       uint64                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"` // 资料版本，新用户为 1
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -127,6 +179,13 @@ func (x *CreateUserResponse) GetEmail() string {
 	return ""
 }
 
+func (x *CreateUserResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 // 验证 Token 请求
 type ValidateTokenRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -233,27 +292,1216 @@ func (x *ValidateTokenResponse) GetEmail() string {
 	return ""
 }
 
-var File_api_grpc_proto_user_proto protoreflect.FileDescriptor
+// 用户地址
+type Address struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Street        string                 `protobuf:"bytes,2,opt,name=street,proto3" json:"street,omitempty"`
+	City          string                 `protobuf:"bytes,3,opt,name=city,proto3" json:"city,omitempty"`
+	State         string                 `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	PostalCode    string                 `protobuf:"bytes,5,opt,name=postal_code,json=postalCode,proto3" json:"postal_code,omitempty"`
+	Country       string                 `protobuf:"bytes,6,opt,name=country,proto3" json:"country,omitempty"`
+	IsDefault     bool                   `protobuf:"varint,7,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"`
+	Version       uint64                 `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`     // 地址版本，每次更新加 1
+	Type          string                 `protobuf:"bytes,9,opt,name=type,proto3" json:"type,omitempty"`            // shipping、billing 或 other，添加时为空表示 shipping；每种类型恰好有一个默认地址
+	Label         string                 `protobuf:"bytes,10,opt,name=label,proto3" json:"label,omitempty"`         // 用户自定义名称，例如 "家"、"公司"
+	Formatted     string                 `protobuf:"bytes,11,opt,name=formatted,proto3" json:"formatted,omitempty"` // 按国家习惯格式化的地址，只在请求了 format 时返回，添加和更新时忽略
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_api_grpc_proto_user_proto_rawDesc = "" +
-	"\n" +
-	"\x19api/grpc/proto/user.proto\x12\x05proto\"E\n" +
-	"\x11CreateUserRequest\x12\x14\n" +
-	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
-	"\bpassword\x18\x02 \x01(\tR\bpassword\"C\n" +
-	"\x12CreateUserResponse\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12\x14\n" +
-	"\x05email\x18\x02 \x01(\tR\x05email\",\n" +
-	"\x14ValidateTokenRequest\x12\x14\n" +
-	"\x05token\x18\x01 \x01(\tR\x05token\"\\\n" +
-	"\x15ValidateTokenResponse\x12\x14\n" +
-	"\x05valid\x18\x01 \x01(\bR\x05valid\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\x04R\x06userId\x12\x14\n" +
-	"\x05email\x18\x03 \x01(\tR\x05email2\x9c\x01\n" +
-	"\vUserService\x12A\n" +
-	"\n" +
-	"CreateUser\x12\x18.proto.CreateUserRequest\x1a\x19.proto.CreateUserResponse\x12J\n" +
-	"\rValidateToken\x12\x1b.proto.ValidateTokenRequest\x1a\x1c.proto.ValidateTokenResponseB\n" +
+func (x *Address) Reset() {
+	*x = Address{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Address) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Address) ProtoMessage() {}
+
+func (x *Address) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Address.ProtoReflect.Descriptor instead.
+func (*Address) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Address) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Address) GetStreet() string {
+	if x != nil {
+		return x.Street
+	}
+	return ""
+}
+
+func (x *Address) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *Address) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Address) GetPostalCode() string {
+	if x != nil {
+		return x.PostalCode
+	}
+	return ""
+}
+
+func (x *Address) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Address) GetIsDefault() bool {
+	if x != nil {
+		return x.IsDefault
+	}
+	return false
+}
+
+func (x *Address) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Address) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Address) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Address) GetFormatted() string {
+	if x != nil {
+		return x.Formatted
+	}
+	return ""
+}
+
+// 用户资料
+type UserProfile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	FirstName     string                 `protobuf:"bytes,3,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	LastName      string                 `protobuf:"bytes,4,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	Addresses     []*Address             `protobuf:"bytes,5,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	Version       uint64                 `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"` // 资料版本，每次更新加 1，不随地址变化
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserProfile) Reset() {
+	*x = UserProfile{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserProfile) ProtoMessage() {}
+
+func (x *UserProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserProfile.ProtoReflect.Descriptor instead.
+func (*UserProfile) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UserProfile) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *UserProfile) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UserProfile) GetFirstName() string {
+	if x != nil {
+		return x.FirstName
+	}
+	return ""
+}
+
+func (x *UserProfile) GetLastName() string {
+	if x != nil {
+		return x.LastName
+	}
+	return ""
+}
+
+func (x *UserProfile) GetAddresses() []*Address {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *UserProfile) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// 获取用户资料请求
+type GetUserProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserProfileRequest) Reset() {
+	*x = GetUserProfileRequest{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserProfileRequest) ProtoMessage() {}
+
+func (x *GetUserProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserProfileRequest.ProtoReflect.Descriptor instead.
+func (*GetUserProfileRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetUserProfileRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+// 获取用户资料响应
+type GetUserProfileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Profile       *UserProfile           `protobuf:"bytes,1,opt,name=profile,proto3" json:"profile,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserProfileResponse) Reset() {
+	*x = GetUserProfileResponse{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserProfileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserProfileResponse) ProtoMessage() {}
+
+func (x *GetUserProfileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserProfileResponse.ProtoReflect.Descriptor instead.
+func (*GetUserProfileResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetUserProfileResponse) GetProfile() *UserProfile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+// 更新用户资料请求
+type UpdateUserProfileRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	FirstName       string                 `protobuf:"bytes,2,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	LastName        string                 `protobuf:"bytes,3,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	ExpectedVersion uint64                 `protobuf:"varint,4,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // 不为 0 时只在资料仍是该版本时更新，否则返回 FAILED_PRECONDITION
+	// 要更新的字段（first_name、last_name），未设置时更新全部字段
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,5,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateUserProfileRequest) Reset() {
+	*x = UpdateUserProfileRequest{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUserProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUserProfileRequest) ProtoMessage() {}
+
+func (x *UpdateUserProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateUserProfileRequest.ProtoReflect.Descriptor instead.
+func (*UpdateUserProfileRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateUserProfileRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *UpdateUserProfileRequest) GetFirstName() string {
+	if x != nil {
+		return x.FirstName
+	}
+	return ""
+}
+
+func (x *UpdateUserProfileRequest) GetLastName() string {
+	if x != nil {
+		return x.LastName
+	}
+	return ""
+}
+
+func (x *UpdateUserProfileRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *UpdateUserProfileRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+// 更新用户资料响应
+type UpdateUserProfileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Profile       *UserProfile           `protobuf:"bytes,1,opt,name=profile,proto3" json:"profile,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateUserProfileResponse) Reset() {
+	*x = UpdateUserProfileResponse{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUserProfileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUserProfileResponse) ProtoMessage() {}
+
+func (x *UpdateUserProfileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateUserProfileResponse.ProtoReflect.Descriptor instead.
+func (*UpdateUserProfileResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateUserProfileResponse) GetProfile() *UserProfile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+// 获取用户地址列表请求
+type ListAddressesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Format        AddressFormat          `protobuf:"varint,2,opt,name=format,proto3,enum=proto.AddressFormat" json:"format,omitempty"` // 设置时在 address.formatted 中返回格式化后的地址，下同
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAddressesRequest) Reset() {
+	*x = ListAddressesRequest{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAddressesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAddressesRequest) ProtoMessage() {}
+
+func (x *ListAddressesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAddressesRequest.ProtoReflect.Descriptor instead.
+func (*ListAddressesRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListAddressesRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ListAddressesRequest) GetFormat() AddressFormat {
+	if x != nil {
+		return x.Format
+	}
+	return AddressFormat_ADDRESS_FORMAT_UNSPECIFIED
+}
+
+// 获取用户地址列表响应
+type ListAddressesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Addresses     []*Address             `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAddressesResponse) Reset() {
+	*x = ListAddressesResponse{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAddressesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAddressesResponse) ProtoMessage() {}
+
+func (x *ListAddressesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAddressesResponse.ProtoReflect.Descriptor instead.
+func (*ListAddressesResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListAddressesResponse) GetAddresses() []*Address {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+// 添加用户地址请求。与同类型的已有地址重复时不会添加：
+// 已有地址包含新地址的全部内容时返回已有地址，否则返回 ALREADY_EXISTS，ResourceInfo 指向已有地址
+type AddAddressRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Address       *Address               `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"` // 忽略 id 和 version
+	Format        AddressFormat          `protobuf:"varint,3,opt,name=format,proto3,enum=proto.AddressFormat" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddAddressRequest) Reset() {
+	*x = AddAddressRequest{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddAddressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddAddressRequest) ProtoMessage() {}
+
+func (x *AddAddressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddAddressRequest.ProtoReflect.Descriptor instead.
+func (*AddAddressRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *AddAddressRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *AddAddressRequest) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *AddAddressRequest) GetFormat() AddressFormat {
+	if x != nil {
+		return x.Format
+	}
+	return AddressFormat_ADDRESS_FORMAT_UNSPECIFIED
+}
+
+// 添加用户地址响应
+type AddAddressResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       *Address               `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddAddressResponse) Reset() {
+	*x = AddAddressResponse{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddAddressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddAddressResponse) ProtoMessage() {}
+
+func (x *AddAddressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddAddressResponse.ProtoReflect.Descriptor instead.
+func (*AddAddressResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AddAddressResponse) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+// 更新用户地址请求
+type UpdateAddressRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Address         *Address               `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`                                         // address.id 指定要更新的地址，忽略 address.version
+	ExpectedVersion uint64                 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // 不为 0 时只在地址仍是该版本时更新，否则返回 FAILED_PRECONDITION
+	// 要更新的字段（street、city、state、postal_code、country、is_default、type、label），未设置时更新全部字段
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,4,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	Format        AddressFormat          `protobuf:"varint,5,opt,name=format,proto3,enum=proto.AddressFormat" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateAddressRequest) Reset() {
+	*x = UpdateAddressRequest{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAddressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAddressRequest) ProtoMessage() {}
+
+func (x *UpdateAddressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAddressRequest.ProtoReflect.Descriptor instead.
+func (*UpdateAddressRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UpdateAddressRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *UpdateAddressRequest) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *UpdateAddressRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *UpdateAddressRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *UpdateAddressRequest) GetFormat() AddressFormat {
+	if x != nil {
+		return x.Format
+	}
+	return AddressFormat_ADDRESS_FORMAT_UNSPECIFIED
+}
+
+// 更新用户地址响应
+type UpdateAddressResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       *Address               `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateAddressResponse) Reset() {
+	*x = UpdateAddressResponse{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAddressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAddressResponse) ProtoMessage() {}
+
+func (x *UpdateAddressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAddressResponse.ProtoReflect.Descriptor instead.
+func (*UpdateAddressResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UpdateAddressResponse) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+// 删除用户地址请求
+type DeleteAddressRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AddressId       uint64                 `protobuf:"varint,2,opt,name=address_id,json=addressId,proto3" json:"address_id,omitempty"`
+	ExpectedVersion uint64                 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // 不为 0 时只在地址仍是该版本时删除，否则返回 FAILED_PRECONDITION
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteAddressRequest) Reset() {
+	*x = DeleteAddressRequest{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAddressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAddressRequest) ProtoMessage() {}
+
+func (x *DeleteAddressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAddressRequest.ProtoReflect.Descriptor instead.
+func (*DeleteAddressRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DeleteAddressRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *DeleteAddressRequest) GetAddressId() uint64 {
+	if x != nil {
+		return x.AddressId
+	}
+	return 0
+}
+
+func (x *DeleteAddressRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+// 删除用户地址响应
+type DeleteAddressResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAddressResponse) Reset() {
+	*x = DeleteAddressResponse{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAddressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAddressResponse) ProtoMessage() {}
+
+func (x *DeleteAddressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAddressResponse.ProtoReflect.Descriptor instead.
+func (*DeleteAddressResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{17}
+}
+
+// 设置默认地址请求
+type SetDefaultAddressRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AddressId       uint64                 `protobuf:"varint,2,opt,name=address_id,json=addressId,proto3" json:"address_id,omitempty"`
+	ExpectedVersion uint64                 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // 不为 0 时只在地址仍是该版本时修改，否则返回 FAILED_PRECONDITION
+	Format          AddressFormat          `protobuf:"varint,4,opt,name=format,proto3,enum=proto.AddressFormat" json:"format,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SetDefaultAddressRequest) Reset() {
+	*x = SetDefaultAddressRequest{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDefaultAddressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDefaultAddressRequest) ProtoMessage() {}
+
+func (x *SetDefaultAddressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDefaultAddressRequest.ProtoReflect.Descriptor instead.
+func (*SetDefaultAddressRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SetDefaultAddressRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *SetDefaultAddressRequest) GetAddressId() uint64 {
+	if x != nil {
+		return x.AddressId
+	}
+	return 0
+}
+
+func (x *SetDefaultAddressRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *SetDefaultAddressRequest) GetFormat() AddressFormat {
+	if x != nil {
+		return x.Format
+	}
+	return AddressFormat_ADDRESS_FORMAT_UNSPECIFIED
+}
+
+// 设置默认地址响应
+type SetDefaultAddressResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       *Address               `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetDefaultAddressResponse) Reset() {
+	*x = SetDefaultAddressResponse{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDefaultAddressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDefaultAddressResponse) ProtoMessage() {}
+
+func (x *SetDefaultAddressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDefaultAddressResponse.ProtoReflect.Descriptor instead.
+func (*SetDefaultAddressResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SetDefaultAddressResponse) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+// 合并地址请求
+type MergeAddressesRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          uint64                 `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AddressId       uint64                 `protobuf:"varint,2,opt,name=address_id,json=addressId,proto3" json:"address_id,omitempty"`                   // 保留的地址
+	AddressIds      []uint64               `protobuf:"varint,3,rep,packed,name=address_ids,json=addressIds,proto3" json:"address_ids,omitempty"`         // 要合并进来的重复地址，为空时合并 address_id 的全部重复地址
+	ExpectedVersion uint64                 `protobuf:"varint,4,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // 不为 0 时只在保留的地址仍是该版本时合并，否则返回 FAILED_PRECONDITION
+	Format          AddressFormat          `protobuf:"varint,5,opt,name=format,proto3,enum=proto.AddressFormat" json:"format,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *MergeAddressesRequest) Reset() {
+	*x = MergeAddressesRequest{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MergeAddressesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MergeAddressesRequest) ProtoMessage() {}
+
+func (x *MergeAddressesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MergeAddressesRequest.ProtoReflect.Descriptor instead.
+func (*MergeAddressesRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *MergeAddressesRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *MergeAddressesRequest) GetAddressId() uint64 {
+	if x != nil {
+		return x.AddressId
+	}
+	return 0
+}
+
+func (x *MergeAddressesRequest) GetAddressIds() []uint64 {
+	if x != nil {
+		return x.AddressIds
+	}
+	return nil
+}
+
+func (x *MergeAddressesRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *MergeAddressesRequest) GetFormat() AddressFormat {
+	if x != nil {
+		return x.Format
+	}
+	return AddressFormat_ADDRESS_FORMAT_UNSPECIFIED
+}
+
+// 合并地址响应
+type MergeAddressesResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Address          *Address               `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	MergedAddressIds []uint64               `protobuf:"varint,2,rep,packed,name=merged_address_ids,json=mergedAddressIds,proto3" json:"merged_address_ids,omitempty"` // 已删除的重复地址
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MergeAddressesResponse) Reset() {
+	*x = MergeAddressesResponse{}
+	mi := &file_api_grpc_proto_user_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MergeAddressesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MergeAddressesResponse) ProtoMessage() {}
+
+func (x *MergeAddressesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_proto_user_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MergeAddressesResponse.ProtoReflect.Descriptor instead.
+func (*MergeAddressesResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_proto_user_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *MergeAddressesResponse) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *MergeAddressesResponse) GetMergedAddressIds() []uint64 {
+	if x != nil {
+		return x.MergedAddressIds
+	}
+	return nil
+}
+
+var File_api_grpc_proto_user_proto protoreflect.FileDescriptor
+
+const file_api_grpc_proto_user_proto_rawDesc = "" +
+	"\n" +
+	"\x19api/grpc/proto/user.proto\x12\x05proto\x1a google/protobuf/field_mask.proto\"E\n" +
+	"\x11CreateUserRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"]\n" +
+	"\x12CreateUserResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\",\n" +
+	"\x14ValidateTokenRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"\\\n" +
+	"\x15ValidateTokenResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x04R\x06userId\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\"\x97\x02\n" +
+	"\aAddress\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x16\n" +
+	"\x06street\x18\x02 \x01(\tR\x06street\x12\x12\n" +
+	"\x04city\x18\x03 \x01(\tR\x04city\x12\x14\n" +
+	"\x05state\x18\x04 \x01(\tR\x05state\x12\x1f\n" +
+	"\vpostal_code\x18\x05 \x01(\tR\n" +
+	"postalCode\x12\x18\n" +
+	"\acountry\x18\x06 \x01(\tR\acountry\x12\x1d\n" +
+	"\n" +
+	"is_default\x18\a \x01(\bR\tisDefault\x12\x18\n" +
+	"\aversion\x18\b \x01(\x04R\aversion\x12\x12\n" +
+	"\x04type\x18\t \x01(\tR\x04type\x12\x14\n" +
+	"\x05label\x18\n" +
+	" \x01(\tR\x05label\x12\x1c\n" +
+	"\tformatted\x18\v \x01(\tR\tformatted\"\xc0\x01\n" +
+	"\vUserProfile\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1d\n" +
+	"\n" +
+	"first_name\x18\x03 \x01(\tR\tfirstName\x12\x1b\n" +
+	"\tlast_name\x18\x04 \x01(\tR\blastName\x12,\n" +
+	"\taddresses\x18\x05 \x03(\v2\x0e.proto.AddressR\taddresses\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\x04R\aversion\"0\n" +
+	"\x15GetUserProfileRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\"F\n" +
+	"\x16GetUserProfileResponse\x12,\n" +
+	"\aprofile\x18\x01 \x01(\v2\x12.proto.UserProfileR\aprofile\"\xd7\x01\n" +
+	"\x18UpdateUserProfileRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12\x1d\n" +
+	"\n" +
+	"first_name\x18\x02 \x01(\tR\tfirstName\x12\x1b\n" +
+	"\tlast_name\x18\x03 \x01(\tR\blastName\x12)\n" +
+	"\x10expected_version\x18\x04 \x01(\x04R\x0fexpectedVersion\x12;\n" +
+	"\vupdate_mask\x18\x05 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"I\n" +
+	"\x19UpdateUserProfileResponse\x12,\n" +
+	"\aprofile\x18\x01 \x01(\v2\x12.proto.UserProfileR\aprofile\"]\n" +
+	"\x14ListAddressesRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12,\n" +
+	"\x06format\x18\x02 \x01(\x0e2\x14.proto.AddressFormatR\x06format\"E\n" +
+	"\x15ListAddressesResponse\x12,\n" +
+	"\taddresses\x18\x01 \x03(\v2\x0e.proto.AddressR\taddresses\"\x84\x01\n" +
+	"\x11AddAddressRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12(\n" +
+	"\aaddress\x18\x02 \x01(\v2\x0e.proto.AddressR\aaddress\x12,\n" +
+	"\x06format\x18\x03 \x01(\x0e2\x14.proto.AddressFormatR\x06format\">\n" +
+	"\x12AddAddressResponse\x12(\n" +
+	"\aaddress\x18\x01 \x01(\v2\x0e.proto.AddressR\aaddress\"\xef\x01\n" +
+	"\x14UpdateAddressRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12(\n" +
+	"\aaddress\x18\x02 \x01(\v2\x0e.proto.AddressR\aaddress\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x04R\x0fexpectedVersion\x12;\n" +
+	"\vupdate_mask\x18\x04 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12,\n" +
+	"\x06format\x18\x05 \x01(\x0e2\x14.proto.AddressFormatR\x06format\"A\n" +
+	"\x15UpdateAddressResponse\x12(\n" +
+	"\aaddress\x18\x01 \x01(\v2\x0e.proto.AddressR\aaddress\"y\n" +
+	"\x14DeleteAddressRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12\x1d\n" +
+	"\n" +
+	"address_id\x18\x02 \x01(\x04R\taddressId\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x04R\x0fexpectedVersion\"\x17\n" +
+	"\x15DeleteAddressResponse\"\xab\x01\n" +
+	"\x18SetDefaultAddressRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12\x1d\n" +
+	"\n" +
+	"address_id\x18\x02 \x01(\x04R\taddressId\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x04R\x0fexpectedVersion\x12,\n" +
+	"\x06format\x18\x04 \x01(\x0e2\x14.proto.AddressFormatR\x06format\"E\n" +
+	"\x19SetDefaultAddressResponse\x12(\n" +
+	"\aaddress\x18\x01 \x01(\v2\x0e.proto.AddressR\aaddress\"\xc9\x01\n" +
+	"\x15MergeAddressesRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x04R\x06userId\x12\x1d\n" +
+	"\n" +
+	"address_id\x18\x02 \x01(\x04R\taddressId\x12\x1f\n" +
+	"\vaddress_ids\x18\x03 \x03(\x04R\n" +
+	"addressIds\x12)\n" +
+	"\x10expected_version\x18\x04 \x01(\x04R\x0fexpectedVersion\x12,\n" +
+	"\x06format\x18\x05 \x01(\x0e2\x14.proto.AddressFormatR\x06format\"p\n" +
+	"\x16MergeAddressesResponse\x12(\n" +
+	"\aaddress\x18\x01 \x01(\v2\x0e.proto.AddressR\aaddress\x12,\n" +
+	"\x12merged_address_ids\x18\x02 \x03(\x04R\x10mergedAddressIds*n\n" +
+	"\rAddressFormat\x12\x1e\n" +
+	"\x1aADDRESS_FORMAT_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19ADDRESS_FORMAT_MULTI_LINE\x10\x01\x12\x1e\n" +
+	"\x1aADDRESS_FORMAT_SINGLE_LINE\x10\x022\x91\x06\n" +
+	"\vUserService\x12A\n" +
+	"\n" +
+	"CreateUser\x12\x18.proto.CreateUserRequest\x1a\x19.proto.CreateUserResponse\x12J\n" +
+	"\rValidateToken\x12\x1b.proto.ValidateTokenRequest\x1a\x1c.proto.ValidateTokenResponse\x12M\n" +
+	"\x0eGetUserProfile\x12\x1c.proto.GetUserProfileRequest\x1a\x1d.proto.GetUserProfileResponse\x12V\n" +
+	"\x11UpdateUserProfile\x12\x1f.proto.UpdateUserProfileRequest\x1a .proto.UpdateUserProfileResponse\x12J\n" +
+	"\rListAddresses\x12\x1b.proto.ListAddressesRequest\x1a\x1c.proto.ListAddressesResponse\x12A\n" +
+	"\n" +
+	"AddAddress\x12\x18.proto.AddAddressRequest\x1a\x19.proto.AddAddressResponse\x12J\n" +
+	"\rUpdateAddress\x12\x1b.proto.UpdateAddressRequest\x1a\x1c.proto.UpdateAddressResponse\x12J\n" +
+	"\rDeleteAddress\x12\x1b.proto.DeleteAddressRequest\x1a\x1c.proto.DeleteAddressResponse\x12V\n" +
+	"\x11SetDefaultAddress\x12\x1f.proto.SetDefaultAddressRequest\x1a .proto.SetDefaultAddressResponse\x12M\n" +
+	"\x0eMergeAddresses\x12\x1c.proto.MergeAddressesRequest\x1a\x1d.proto.MergeAddressesResponseB\n" +
 	"Z\b./;protob\x06proto3"
 
 var (
@@ -268,23 +1516,77 @@ func file_api_grpc_proto_user_proto_rawDescGZIP() []byte {
 	return file_api_grpc_proto_user_proto_rawDescData
 }
 
-var file_api_grpc_proto_user_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_api_grpc_proto_user_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_api_grpc_proto_user_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_api_grpc_proto_user_proto_goTypes = []any{
-	(*CreateUserRequest)(nil),     // 0: proto.CreateUserRequest
-	(*CreateUserResponse)(nil),    // 1: proto.CreateUserResponse
-	(*ValidateTokenRequest)(nil),  // 2: proto.ValidateTokenRequest
-	(*ValidateTokenResponse)(nil), // 3: proto.ValidateTokenResponse
+	(AddressFormat)(0),                // 0: proto.AddressFormat
+	(*CreateUserRequest)(nil),         // 1: proto.CreateUserRequest
+	(*CreateUserResponse)(nil),        // 2: proto.CreateUserResponse
+	(*ValidateTokenRequest)(nil),      // 3: proto.ValidateTokenRequest
+	(*ValidateTokenResponse)(nil),     // 4: proto.ValidateTokenResponse
+	(*Address)(nil),                   // 5: proto.Address
+	(*UserProfile)(nil),               // 6: proto.UserProfile
+	(*GetUserProfileRequest)(nil),     // 7: proto.GetUserProfileRequest
+	(*GetUserProfileResponse)(nil),    // 8: proto.GetUserProfileResponse
+	(*UpdateUserProfileRequest)(nil),  // 9: proto.UpdateUserProfileRequest
+	(*UpdateUserProfileResponse)(nil), // 10: proto.UpdateUserProfileResponse
+	(*ListAddressesRequest)(nil),      // 11: proto.ListAddressesRequest
+	(*ListAddressesResponse)(nil),     // 12: proto.ListAddressesResponse
+	(*AddAddressRequest)(nil),         // 13: proto.AddAddressRequest
+	(*AddAddressResponse)(nil),        // 14: proto.AddAddressResponse
+	(*UpdateAddressRequest)(nil),      // 15: proto.UpdateAddressRequest
+	(*UpdateAddressResponse)(nil),     // 16: proto.UpdateAddressResponse
+	(*DeleteAddressRequest)(nil),      // 17: proto.DeleteAddressRequest
+	(*DeleteAddressResponse)(nil),     // 18: proto.DeleteAddressResponse
+	(*SetDefaultAddressRequest)(nil),  // 19: proto.SetDefaultAddressRequest
+	(*SetDefaultAddressResponse)(nil), // 20: proto.SetDefaultAddressResponse
+	(*MergeAddressesRequest)(nil),     // 21: proto.MergeAddressesRequest
+	(*MergeAddressesResponse)(nil),    // 22: proto.MergeAddressesResponse
+	(*fieldmaskpb.FieldMask)(nil),     // 23: google.protobuf.FieldMask
 }
 var file_api_grpc_proto_user_proto_depIdxs = []int32{
-	0, // 0: proto.UserService.CreateUser:input_type -> proto.CreateUserRequest
-	2, // 1: proto.UserService.ValidateToken:input_type -> proto.ValidateTokenRequest
-	1, // 2: proto.UserService.CreateUser:output_type -> proto.CreateUserResponse
-	3, // 3: proto.UserService.ValidateToken:output_type -> proto.ValidateTokenResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	5,  // 0: proto.UserProfile.addresses:type_name -> proto.Address
+	6,  // 1: proto.GetUserProfileResponse.profile:type_name -> proto.UserProfile
+	23, // 2: proto.UpdateUserProfileRequest.update_mask:type_name -> google.protobuf.FieldMask
+	6,  // 3: proto.UpdateUserProfileResponse.profile:type_name -> proto.UserProfile
+	0,  // 4: proto.ListAddressesRequest.format:type_name -> proto.AddressFormat
+	5,  // 5: proto.ListAddressesResponse.addresses:type_name -> proto.Address
+	5,  // 6: proto.AddAddressRequest.address:type_name -> proto.Address
+	0,  // 7: proto.AddAddressRequest.format:type_name -> proto.AddressFormat
+	5,  // 8: proto.AddAddressResponse.address:type_name -> proto.Address
+	5,  // 9: proto.UpdateAddressRequest.address:type_name -> proto.Address
+	23, // 10: proto.UpdateAddressRequest.update_mask:type_name -> google.protobuf.FieldMask
+	0,  // 11: proto.UpdateAddressRequest.format:type_name -> proto.AddressFormat
+	5,  // 12: proto.UpdateAddressResponse.address:type_name -> proto.Address
+	0,  // 13: proto.SetDefaultAddressRequest.format:type_name -> proto.AddressFormat
+	5,  // 14: proto.SetDefaultAddressResponse.address:type_name -> proto.Address
+	0,  // 15: proto.MergeAddressesRequest.format:type_name -> proto.AddressFormat
+	5,  // 16: proto.MergeAddressesResponse.address:type_name -> proto.Address
+	1,  // 17: proto.UserService.CreateUser:input_type -> proto.CreateUserRequest
+	3,  // 18: proto.UserService.ValidateToken:input_type -> proto.ValidateTokenRequest
+	7,  // 19: proto.UserService.GetUserProfile:input_type -> proto.GetUserProfileRequest
+	9,  // 20: proto.UserService.UpdateUserProfile:input_type -> proto.UpdateUserProfileRequest
+	11, // 21: proto.UserService.ListAddresses:input_type -> proto.ListAddressesRequest
+	13, // 22: proto.UserService.AddAddress:input_type -> proto.AddAddressRequest
+	15, // 23: proto.UserService.UpdateAddress:input_type -> proto.UpdateAddressRequest
+	17, // 24: proto.UserService.DeleteAddress:input_type -> proto.DeleteAddressRequest
+	19, // 25: proto.UserService.SetDefaultAddress:input_type -> proto.SetDefaultAddressRequest
+	21, // 26: proto.UserService.MergeAddresses:input_type -> proto.MergeAddressesRequest
+	2,  // 27: proto.UserService.CreateUser:output_type -> proto.CreateUserResponse
+	4,  // 28: proto.UserService.ValidateToken:output_type -> proto.ValidateTokenResponse
+	8,  // 29: proto.UserService.GetUserProfile:output_type -> proto.GetUserProfileResponse
+	10, // 30: proto.UserService.UpdateUserProfile:output_type -> proto.UpdateUserProfileResponse
+	12, // 31: proto.UserService.ListAddresses:output_type -> proto.ListAddressesResponse
+	14, // 32: proto.UserService.AddAddress:output_type -> proto.AddAddressResponse
+	16, // 33: proto.UserService.UpdateAddress:output_type -> proto.UpdateAddressResponse
+	18, // 34: proto.UserService.DeleteAddress:output_type -> proto.DeleteAddressResponse
+	20, // 35: proto.UserService.SetDefaultAddress:output_type -> proto.SetDefaultAddressResponse
+	22, // 36: proto.UserService.MergeAddresses:output_type -> proto.MergeAddressesResponse
+	27, // [27:37] is the sub-list for method output_type
+	17, // [17:27] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
 }
 
 func init() { file_api_grpc_proto_user_proto_init() }
@@ -297,13 +1599,14 @@ func file_api_grpc_proto_user_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_grpc_proto_user_proto_rawDesc), len(file_api_grpc_proto_user_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      1,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_api_grpc_proto_user_proto_goTypes,
 		DependencyIndexes: file_api_grpc_proto_user_proto_depIdxs,
+		EnumInfos:         file_api_grpc_proto_user_proto_enumTypes,
 		MessageInfos:      file_api_grpc_proto_user_proto_msgTypes,
 	}.Build()
 	File_api_grpc_proto_user_proto = out.File
@@ -2,11 +2,21 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
 
 	pb "github.com/blackwatch66/user-microservice/api/grpc/proto"
+	"github.com/blackwatch66/user-microservice/internal/country"
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"github.com/blackwatch66/user-microservice/internal/model"
 	"github.com/blackwatch66/user-microservice/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 // UserServer 实现了 UserServiceServer gRPC 接口
@@ -30,15 +40,16 @@ func (s *UserServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 	user, err := s.userService.Register(ctx, req.Email, req.Password)
 	if err != nil {
 		// 根据 service 层返回的错误转换 gRPC 状态码
-		if err.Error() == "email already exists" {
+		if errors.Is(err, service.ErrEmailExists) {
 			return nil, status.Errorf(codes.AlreadyExists, err.Error())
 		}
 		return nil, status.Errorf(codes.Internal, "Failed to create user: %v", err)
 	}
 
 	return &pb.CreateUserResponse{
-		UserId: uint64(user.ID),
-		Email:  user.Email,
+		UserId:  uint64(user.ID),
+		Email:   user.Email,
+		Version: user.Version,
 	}, nil
 }
 
@@ -49,6 +60,11 @@ func (s *UserServer) ValidateToken(ctx context.Context, req *pb.ValidateTokenReq
 	}
 
 	claims, err := s.userService.ValidateToken(ctx, req.Token)
+	metrics.ObserveTokenValidation("grpc", err)
+	if errors.Is(err, service.ErrUnavailable) {
+		// REDIS_POLICY_REVOCATION=fail_closed 时无法确认 token 未被吊销，不能答复为无效
+		return nil, status.Error(codes.Unavailable, "token revocation cannot be checked")
+	}
 	if err != nil {
 		// Token 无效或过期
 		return &pb.ValidateTokenResponse{Valid: false}, nil // 返回无效，不暴露具体错误给调用方
@@ -61,4 +77,389 @@ func (s *UserServer) ValidateToken(ctx context.Context, req *pb.ValidateTokenReq
 		UserId: uint64(claims.UserID),
 		Email:  claims.Email,
 	}, nil
-} 
\ No newline at end of file
+}
+
+// GetUserProfile 实现 gRPC 的 GetUserProfile 方法
+func (s *UserServer) GetUserProfile(ctx context.Context, req *pb.GetUserProfileRequest) (*pb.GetUserProfileResponse, error) {
+	userID, err := userIDFromRequest(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserProfile(ctx, userID)
+	if err != nil {
+		return nil, serviceError(err, "Failed to get user profile")
+	}
+	return &pb.GetUserProfileResponse{Profile: toProtoProfile(user)}, nil
+}
+
+// UpdateUserProfile 实现 gRPC 的 UpdateUserProfile 方法
+func (s *UserServer) UpdateUserProfile(ctx context.Context, req *pb.UpdateUserProfileRequest) (*pb.UpdateUserProfileResponse, error) {
+	userID, err := userIDFromRequest(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := profilePatch(req)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.PatchUserProfile(ctx, userID, req.ExpectedVersion, patch)
+	if err != nil {
+		return nil, serviceError(err, "Failed to update user profile")
+	}
+	// 与 HTTP 的 PUT 一样，返回的资料不含地址
+	return &pb.UpdateUserProfileResponse{Profile: toProtoProfile(user)}, nil
+}
+
+// ListAddresses 实现 gRPC 的 ListAddresses 方法
+func (s *UserServer) ListAddresses(ctx context.Context, req *pb.ListAddressesRequest) (*pb.ListAddressesResponse, error) {
+	userID, err := userIDFromRequest(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := addressFormatter(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.userService.GetUserAddresses(ctx, userID)
+	if err != nil {
+		return nil, serviceError(err, "Failed to list addresses")
+	}
+	resp := &pb.ListAddressesResponse{Addresses: make([]*pb.Address, 0, len(addresses))}
+	for i := range addresses {
+		resp.Addresses = append(resp.Addresses, toFormattedAddress(&addresses[i], format))
+	}
+	return resp, nil
+}
+
+// AddAddress 实现 gRPC 的 AddAddress 方法
+func (s *UserServer) AddAddress(ctx context.Context, req *pb.AddAddressRequest) (*pb.AddAddressResponse, error) {
+	userID, err := userIDFromRequest(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if req.Address == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Address is required")
+	}
+	format, err := addressFormatter(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := s.userService.AddUserAddress(ctx, userID, fromProtoAddress(req.Address))
+	var duplicate *service.DuplicateAddressError
+	if errors.As(err, &duplicate) && duplicate.Exact {
+		// 已有地址包含新地址的全部内容，视为添加成功
+		address, err = &duplicate.Existing, nil
+	}
+	if err != nil {
+		return nil, serviceError(err, "Failed to add address")
+	}
+	return &pb.AddAddressResponse{Address: toFormattedAddress(address, format)}, nil
+}
+
+// UpdateAddress 实现 gRPC 的 UpdateAddress 方法
+func (s *UserServer) UpdateAddress(ctx context.Context, req *pb.UpdateAddressRequest) (*pb.UpdateAddressResponse, error) {
+	userID, err := userIDFromRequest(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if req.Address == nil || req.Address.Id == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Address with an id is required")
+	}
+
+	patch, err := addressPatch(req)
+	if err != nil {
+		return nil, err
+	}
+	format, err := addressFormatter(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := s.userService.PatchUserAddress(ctx, userID, uint(req.Address.Id), req.ExpectedVersion, patch)
+	if err != nil {
+		return nil, serviceError(err, "Failed to update address")
+	}
+	return &pb.UpdateAddressResponse{Address: toFormattedAddress(address, format)}, nil
+}
+
+// DeleteAddress 实现 gRPC 的 DeleteAddress 方法
+func (s *UserServer) DeleteAddress(ctx context.Context, req *pb.DeleteAddressRequest) (*pb.DeleteAddressResponse, error) {
+	userID, err := userIDFromRequest(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if req.AddressId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Address ID is required")
+	}
+
+	if err := s.userService.DeleteUserAddress(ctx, userID, uint(req.AddressId), req.ExpectedVersion); err != nil {
+		return nil, serviceError(err, "Failed to delete address")
+	}
+	return &pb.DeleteAddressResponse{}, nil
+}
+
+// SetDefaultAddress 把地址设为同类型的默认地址
+func (s *UserServer) SetDefaultAddress(ctx context.Context, req *pb.SetDefaultAddressRequest) (*pb.SetDefaultAddressResponse, error) {
+	userID, err := userIDFromRequest(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if req.AddressId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Address ID is required")
+	}
+	format, err := addressFormatter(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := s.userService.SetDefaultAddress(ctx, userID, uint(req.AddressId), req.ExpectedVersion)
+	if err != nil {
+		return nil, serviceError(err, "Failed to set default address")
+	}
+	return &pb.SetDefaultAddressResponse{Address: toFormattedAddress(addr, format)}, nil
+}
+
+// MergeAddresses 把重复的地址合并到 address_id，重复地址被删除
+func (s *UserServer) MergeAddresses(ctx context.Context, req *pb.MergeAddressesRequest) (*pb.MergeAddressesResponse, error) {
+	userID, err := userIDFromRequest(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if req.AddressId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Address ID is required")
+	}
+	format, err := addressFormatter(req.Format)
+	if err != nil {
+		return nil, err
+	}
+	duplicateIDs := make([]uint, 0, len(req.AddressIds))
+	for _, id := range req.AddressIds {
+		duplicateIDs = append(duplicateIDs, uint(id))
+	}
+
+	addr, mergedIDs, err := s.userService.MergeUserAddresses(ctx, userID, uint(req.AddressId), req.ExpectedVersion, duplicateIDs)
+	if err != nil {
+		return nil, serviceError(err, "Failed to merge addresses")
+	}
+	resp := &pb.MergeAddressesResponse{Address: toFormattedAddress(addr, format), MergedAddressIds: make([]uint64, 0, len(mergedIDs))}
+	for _, id := range mergedIDs {
+		resp.MergedAddressIds = append(resp.MergedAddressIds, uint64(id))
+	}
+	return resp, nil
+}
+
+// userIDFromRequest 校验请求中的用户 ID
+func userIDFromRequest(id uint64) (uint, error) {
+	if id == 0 || id > math.MaxUint32 {
+		return 0, status.Errorf(codes.InvalidArgument, "Invalid user ID")
+	}
+	return uint(id), nil
+}
+
+// profilePatch 按 update_mask 选出要更新的资料字段，未设置 mask 时更新全部字段
+func profilePatch(req *pb.UpdateUserProfileRequest) (service.ProfilePatch, error) {
+	paths, err := maskPaths(req.UpdateMask, "first_name", "last_name")
+	if err != nil {
+		return service.ProfilePatch{}, err
+	}
+	var patch service.ProfilePatch
+	for _, path := range paths {
+		switch path {
+		case "first_name":
+			patch.FirstName = &req.FirstName
+		case "last_name":
+			patch.LastName = &req.LastName
+		}
+	}
+	return patch, nil
+}
+
+// addressPatch 按 update_mask 选出要更新的地址字段，未设置 mask 时更新全部字段
+func addressPatch(req *pb.UpdateAddressRequest) (service.AddressPatch, error) {
+	paths, err := maskPaths(req.UpdateMask, "street", "city", "state", "postal_code", "country", "is_default", "type", "label")
+	if err != nil {
+		return service.AddressPatch{}, err
+	}
+	addr := req.Address
+	var patch service.AddressPatch
+	for _, path := range paths {
+		switch path {
+		case "street":
+			patch.Street = &addr.Street
+		case "city":
+			patch.City = &addr.City
+		case "state":
+			patch.State = &addr.State
+		case "postal_code":
+			patch.PostalCode = &addr.PostalCode
+		case "country":
+			patch.Country = &addr.Country
+		case "is_default":
+			patch.IsDefault = &addr.IsDefault
+		case "type":
+			// proto3 无法区分空字符串和未设置，空类型与添加地址时一样视为 shipping
+			addrType := addr.Type
+			if addrType == "" {
+				addrType = model.AddressTypeShipping
+			}
+			patch.Type = &addrType
+		case "label":
+			patch.Label = &addr.Label
+		}
+	}
+	return patch, nil
+}
+
+// maskPaths 校验 mask 中的字段路径，mask 未设置、为空或为 * 时返回全部 fields
+func maskPaths(mask *fieldmaskpb.FieldMask, fields ...string) ([]string, error) {
+	paths := mask.GetPaths()
+	if len(paths) == 0 || (len(paths) == 1 && paths[0] == "*") {
+		return fields, nil
+	}
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field] = true
+	}
+	violations := &errdetails.BadRequest{}
+	for _, path := range paths {
+		if !known[path] {
+			violations.FieldViolations = append(violations.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       "update_mask",
+				Description: fmt.Sprintf("%q is not a field that can be updated", path),
+			})
+		}
+	}
+	if len(violations.FieldViolations) > 0 {
+		return nil, invalidArgument("Invalid update_mask", violations)
+	}
+	return paths, nil
+}
+
+// invalidArgument 返回带有字段错误详情的 InvalidArgument
+func invalidArgument(message string, violations *errdetails.BadRequest) error {
+	return statusWithDetails(codes.InvalidArgument, message, violations)
+}
+
+// statusWithDetails 返回附带错误详情的状态，详情无法编码时只返回状态
+func statusWithDetails(code codes.Code, message string, details protoadapt.MessageV1) error {
+	st, err := status.New(code, message).WithDetails(details)
+	if err != nil {
+		return status.Error(code, message)
+	}
+	return st.Err()
+}
+
+// serviceError 把 service 层的错误转换为 gRPC 状态
+func serviceError(err error, message string) error {
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		violations := &errdetails.BadRequest{}
+		fields := make([]string, 0, len(validationErr.Fields))
+		for field := range validationErr.Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			violations.FieldViolations = append(violations.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field,
+				Description: validationErr.Fields[field],
+			})
+		}
+		return invalidArgument(err.Error(), violations)
+	}
+	var duplicate *service.DuplicateAddressError
+	if errors.As(err, &duplicate) {
+		return statusWithDetails(codes.AlreadyExists, err.Error(), &errdetails.ResourceInfo{
+			ResourceType: "Address",
+			ResourceName: fmt.Sprintf("users/%d/addresses/%d", duplicate.Existing.UserID, duplicate.Existing.ID),
+			Description:  "an address of the same type with the same location already exists",
+		})
+	}
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrAddressNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrVersionMismatch):
+		// 与 HTTP 的 412 对应，客户端需要重新读取后再修改
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, service.ErrAddressLimitReached):
+		return statusWithDetails(codes.FailedPrecondition, err.Error(), &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{Type: "ADDRESS_LIMIT", Subject: "addresses", Description: err.Error()}},
+		})
+	}
+	return status.Errorf(codes.Internal, "%s: %v", message, err)
+}
+
+// toProtoProfile 转换用户资料，不包含密码哈希
+func toProtoProfile(user *model.User) *pb.UserProfile {
+	profile := &pb.UserProfile{
+		UserId:    uint64(user.ID),
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Version:   user.Version,
+		Addresses: make([]*pb.Address, 0, len(user.Addresses)),
+	}
+	for i := range user.Addresses {
+		profile.Addresses = append(profile.Addresses, toProtoAddress(&user.Addresses[i]))
+	}
+	return profile
+}
+
+func toProtoAddress(addr *model.Address) *pb.Address {
+	return &pb.Address{
+		Id:         uint64(addr.ID),
+		Street:     addr.Street,
+		City:       addr.City,
+		State:      addr.State,
+		PostalCode: addr.PostalCode,
+		Country:    addr.Country,
+		IsDefault:  addr.IsDefault,
+		Version:    addr.Version,
+		Type:       addr.Type,
+		Label:      addr.Label,
+	}
+}
+
+// addressFormatter 返回 format 对应的格式化函数，未设置时返回 nil
+func addressFormatter(format pb.AddressFormat) (func(*model.Address) string, error) {
+	switch format {
+	case pb.AddressFormat_ADDRESS_FORMAT_UNSPECIFIED:
+		return nil, nil
+	case pb.AddressFormat_ADDRESS_FORMAT_MULTI_LINE:
+		return country.MultiLine, nil
+	case pb.AddressFormat_ADDRESS_FORMAT_SINGLE_LINE:
+		return country.SingleLine, nil
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "Unknown address format %d", format)
+}
+
+// toFormattedAddress 同 toProtoAddress，format 不为 nil 时附带格式化后的地址
+func toFormattedAddress(addr *model.Address, format func(*model.Address) string) *pb.Address {
+	address := toProtoAddress(addr)
+	if format != nil {
+		address.Formatted = format(addr)
+	}
+	return address
+}
+
+// fromProtoAddress 只取地址字段，ID、版本和所属用户由 service 层决定
+func fromProtoAddress(addr *pb.Address) model.Address {
+	return model.Address{
+		Street:     addr.Street,
+		City:       addr.City,
+		State:      addr.State,
+		PostalCode: addr.PostalCode,
+		Country:    addr.Country,
+		IsDefault:  addr.IsDefault,
+		Type:       addr.Type,
+		Label:      addr.Label,
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/blackwatch66/user-microservice/internal/country"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// format 查询参数的取值
+const (
+	AddressFormatMultiLine  = "multiline"  // 多行，用于打印标签和信封
+	AddressFormatSingleLine = "singleline" // 单行，用于邮件和列表
+)
+
+// addressFormat 按国家习惯把地址格式化为文本
+type addressFormat func(addr *model.Address) string
+
+// formattedAddress 在地址字段之外附带格式化后的地址
+type formattedAddress struct {
+	model.Address
+	Formatted string `json:"formatted"`
+}
+
+// addressFormatFromQuery 解析 format 查询参数，没有该参数时返回 nil，无效的值返回 400
+func addressFormatFromQuery(c *gin.Context) (addressFormat, bool) {
+	switch c.Query("format") {
+	case "":
+		return nil, true
+	case AddressFormatMultiLine:
+		return country.MultiLine, true
+	case AddressFormatSingleLine:
+		return country.SingleLine, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "format must be " + AddressFormatMultiLine + " or " + AddressFormatSingleLine})
+	return nil, false
+}
+
+// render 返回响应体：请求了 format 时附带格式化后的地址，否则原样返回地址
+func (f addressFormat) render(addr *model.Address) interface{} {
+	if f == nil {
+		return addr
+	}
+	return formattedAddress{Address: *addr, Formatted: f(addr)}
+}
+
+// renderAll 同 render，用于地址列表
+func (f addressFormat) renderAll(addresses []model.Address) interface{} {
+	if f == nil {
+		return addresses
+	}
+	formatted := make([]formattedAddress, len(addresses))
+	for i := range addresses {
+		formatted[i] = formattedAddress{Address: addresses[i], Formatted: f(&addresses[i])}
+	}
+	return formatted
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/blackwatch66/user-microservice/internal/country"
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+func TestAddressFormatRender(t *testing.T) {
+	addr := model.Address{Street: "1 Infinite Loop", City: "Cupertino", State: "CA", PostalCode: "95014", Country: "US"}
+	tests := []struct {
+		name          string
+		format        addressFormat
+		wantFormatted string // empty when the response must not have the field
+	}{
+		{"no format", nil, ""},
+		{"multi line", country.MultiLine, "1 Infinite Loop\nCupertino, CA 95014\nUnited States"},
+		{"single line", country.SingleLine, "1 Infinite Loop, Cupertino, CA 95014, United States"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.format.render(&addr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields map[string]interface{}
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatal(err)
+			}
+			formatted, ok := fields["formatted"]
+			if tt.wantFormatted == "" {
+				if ok {
+					t.Errorf("response has formatted = %v, want no such field", formatted)
+				}
+				return
+			}
+			if formatted != tt.wantFormatted {
+				t.Errorf("formatted = %v, want %q in %s", formatted, tt.wantFormatted, data)
+			}
+			plain, _ := json.Marshal(addr)
+			var addressFields map[string]interface{}
+			if err := json.Unmarshal(plain, &addressFields); err != nil {
+				t.Fatal(err)
+			}
+			for key := range addressFields {
+				if _, ok := fields[key]; !ok {
+					t.Errorf("response %s lost the address field %s", data, key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/blackwatch66/user-microservice/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler 提供审计日志查询接口（仅限管理员）
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+// NewAuditHandler 创建一个新的 AuditHandler
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// RegisterRoutes 注册审计日志路由
+func (h *AuditHandler) RegisterRoutes(router *gin.Engine, adminMiddleware gin.HandlerFunc) {
+	auditGroup := router.Group("/api/admin/audit-logs")
+	auditGroup.Use(adminMiddleware)
+	{
+		auditGroup.GET("", h.List) // GET /api/admin/audit-logs
+	}
+}
+
+// auditLogResponse 审计日志的响应格式
+type auditLogResponse struct {
+	ID           uint64          `json:"id"`
+	OccurredAt   time.Time       `json:"occurred_at"`
+	Action       string          `json:"action"`
+	Outcome      string          `json:"outcome"`
+	ActorID      *uint           `json:"actor_id"`
+	ActorType    string          `json:"actor_type"`
+	TargetUserID *uint           `json:"target_user_id"`
+	IP           string          `json:"ip"`
+	UserAgent    string          `json:"user_agent"`
+	RequestID    string          `json:"request_id"`
+	Changes      json.RawMessage `json:"changes,omitempty"`
+	Details      json.RawMessage `json:"details,omitempty"`
+}
+
+func newAuditLogResponse(entry *model.AuditLog) auditLogResponse {
+	resp := auditLogResponse{
+		ID:           entry.ID,
+		OccurredAt:   entry.OccurredAt,
+		Action:       entry.Action,
+		Outcome:      entry.Outcome,
+		ActorID:      entry.ActorID,
+		ActorType:    entry.ActorType,
+		TargetUserID: entry.TargetUserID,
+		IP:           entry.IP,
+		UserAgent:    entry.UserAgent,
+		RequestID:    entry.RequestID,
+	}
+	if entry.Changes != "" {
+		resp.Changes = json.RawMessage(entry.Changes)
+	}
+	if entry.Details != "" {
+		resp.Details = json.RawMessage(entry.Details)
+	}
+	return resp
+}
+
+// List 按条件查询审计日志，按时间倒序返回，使用 cursor 翻页
+func (h *AuditHandler) List(c *gin.Context) {
+	filter := service.AuditFilter{
+		ActorType: c.Query("actor_type"),
+		Action:    c.Query("action"),
+		Outcome:   c.Query("outcome"),
+		IP:        c.Query("ip"),
+		RequestID: c.Query("request_id"),
+	}
+
+	var err error
+	if filter.ActorID, err = parseUintQuery(c, "actor_id"); err != nil {
+		return
+	}
+	if filter.TargetUserID, err = parseUintQuery(c, "target_user_id"); err != nil {
+		return
+	}
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		if filter.BeforeID, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+	}
+	filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || filter.Limit < 1 || filter.Limit > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit, must be between 1 and 1000"})
+		return
+	}
+
+	entries, err := h.auditService.Query(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query audit log", "details": err.Error()})
+		return
+	}
+
+	items := make([]auditLogResponse, 0, len(entries))
+	for i := range entries {
+		items = append(items, newAuditLogResponse(&entries[i]))
+	}
+	resp := gin.H{"items": items}
+	// 返回满页时提供下一页的 cursor
+	if len(entries) == filter.Limit {
+		resp["next_cursor"] = strconv.FormatUint(entries[len(entries)-1].ID, 10)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Helper function to parse an optional unsigned integer query parameter
+func parseUintQuery(c *gin.Context, name string) (uint, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " format"})
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// Helper function to parse an optional RFC 3339 time query parameter
+func parseTimeQuery(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " format, expected RFC 3339"})
+		return time.Time{}, err
+	}
+	return t, nil
+}
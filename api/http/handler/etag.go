@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag 把资源版本作为强 ETag 写入响应头，例如 "3"
+func setETag(c *gin.Context, version uint64) {
+	c.Header("ETag", strconv.Quote(strconv.FormatUint(version, 10)))
+}
+
+// ifMatchVersion 解析 If-Match 请求头，返回客户端要求的资源版本。
+// 没有请求头或为 * 时返回 0，表示不限制版本；
+// 无法解析的值（包括弱 ETag，If-Match 只做强比较）不可能与当前版本匹配，直接返回 412，ok 为 false
+func ifMatchVersion(c *gin.Context) (version uint64, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	if strings.Contains(header, ",") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must contain a single entity tag"})
+		return 0, false
+	}
+	unquoted, err := strconv.Unquote(header)
+	if err == nil {
+		version, err = strconv.ParseUint(unquoted, 10, 64)
+	}
+	if err != nil || version == 0 {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match the current version"})
+		return 0, false
+	}
+	return version, true
+}
+
+// versionMismatch 响应版本冲突：带 If-Match 的请求返回 412，
+// 没有 If-Match 时说明在读取和写入之间被并发修改，返回 409
+func versionMismatch(c *gin.Context, err error) {
+	if c.GetHeader("If-Match") != "" {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/health"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler 提供存活（liveness）与就绪（readiness）探针
+type HealthHandler struct {
+	checker   *health.Checker
+	startedAt time.Time
+}
+
+// NewHealthHandler 创建一个新的 HealthHandler
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker, startedAt: time.Now()}
+}
+
+// RegisterRoutes 注册健康检查路由（无需认证）
+func (h *HealthHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/healthz", h.Liveness) // GET /healthz
+	router.GET("/readyz", h.Readiness) // GET /readyz
+}
+
+// dependencyStatus 单个依赖的检查结果
+type dependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Optional  bool   `json:"optional,omitempty"`
+	// Degraded 可选依赖不可用时，各个依赖它的功能的降级策略
+	Degraded map[string]string `json:"degraded_features,omitempty"`
+}
+
+// Liveness 只反映进程自身是否存活，不检查外部依赖，避免依赖故障导致容器被反复重启
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "ok",
+		"uptime_seconds": int64(time.Since(h.startedAt).Seconds()),
+	})
+}
+
+// Readiness 检查数据库与 Redis 是否可用，优雅关闭期间始终返回 503。
+// Redis 是可选依赖：不可用时仍返回 200，状态为 degraded，并列出各功能的降级策略
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	if h.checker.ShuttingDown() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down"})
+		return
+	}
+
+	results := h.checker.Run(c.Request.Context())
+	checks := make(map[string]dependencyStatus, len(results))
+	for _, result := range results {
+		dep := dependencyStatus{Status: "up", LatencyMs: result.Duration.Milliseconds(), Optional: result.Optional}
+		if result.Err != nil {
+			dep.Status = "down"
+			dep.Error = result.Err.Error()
+			dep.Degraded = result.Degraded
+		}
+		checks[result.Name] = dep
+	}
+
+	// 检查期间可能已经开始关闭
+	if h.checker.ShuttingDown() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down", "checks": checks})
+		return
+	}
+
+	if !health.AllHealthy(results) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready", "checks": checks})
+		return
+	}
+	if health.Degraded(results) {
+		c.JSON(http.StatusOK, gin.H{"status": "degraded", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": checks})
+}
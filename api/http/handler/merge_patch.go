@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/blackwatch66/user-microservice/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// MergePatchContentType RFC 7396 JSON Merge Patch 的媒体类型
+const MergePatchContentType = "application/merge-patch+json"
+
+// mergePatch 是一个 JSON Merge Patch 文档：出现的字段被修改，值为 null 的字段被清空，未出现的字段保持不变。
+// 资源都是扁平对象，所以只处理顶层字段
+type mergePatch struct {
+	fields map[string]json.RawMessage
+	errs   map[string]string
+}
+
+// bindMergePatch 读取 PATCH 请求体，allowed 之外的字段返回 400，避免拼错的字段被静默忽略
+func bindMergePatch(c *gin.Context, allowed ...string) (*mergePatch, bool) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType != MergePatchContentType && mediaType != "application/json" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + MergePatchContentType})
+		return nil, false
+	}
+
+	var fields map[string]json.RawMessage
+	decoder := json.NewDecoder(c.Request.Body)
+	if err := decoder.Decode(&fields); err != nil || fields == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": "request body must be a JSON object"})
+		return nil, false
+	}
+
+	patch := &mergePatch{fields: fields, errs: make(map[string]string)}
+	known := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		known[name] = true
+	}
+	for name := range fields {
+		if !known[name] {
+			patch.errs[name] = "is not a field that can be changed"
+		}
+	}
+	return patch, true
+}
+
+// string 返回字段的新值，字段未出现时返回 nil，null 清空为空字符串
+func (p *mergePatch) string(name string) *string {
+	raw, ok := p.fields[name]
+	if !ok {
+		return nil
+	}
+	var value string
+	if !isNull(raw) {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			p.errs[name] = "must be a string"
+			return nil
+		}
+	}
+	return &value
+}
+
+// bool 返回字段的新值，字段未出现时返回 nil，null 清空为 false
+func (p *mergePatch) bool(name string) *bool {
+	raw, ok := p.fields[name]
+	if !ok {
+		return nil
+	}
+	var value bool
+	if !isNull(raw) {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			p.errs[name] = "must be a boolean"
+			return nil
+		}
+	}
+	return &value
+}
+
+// valid 在有字段错误时返回 400 并返回 false
+func (p *mergePatch) valid(c *gin.Context) bool {
+	if len(p.errs) == 0 {
+		return true
+	}
+	respondInvalidInput(c, &service.ValidationError{Message: "invalid merge patch", Fields: p.errs})
+	return false
+}
+
+func isNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+// respondInvalidInput 返回 400，fields 列出每个无效字段的原因
+func respondInvalidInput(c *gin.Context, err *service.ValidationError) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error(), "fields": err.Fields})
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -10,6 +12,31 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// addressRequest 添加和整体更新地址的请求体
+type addressRequest struct {
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	IsDefault  bool   `json:"is_default"`
+	Type       string `json:"type"`
+	Label      string `json:"label"`
+}
+
+func (r addressRequest) toModel() model.Address {
+	return model.Address{
+		Street:     r.Street,
+		City:       r.City,
+		State:      r.State,
+		PostalCode: r.PostalCode,
+		Country:    r.Country,
+		IsDefault:  r.IsDefault,
+		Type:       r.Type,
+		Label:      r.Label,
+	}
+}
+
 // UserHandler 封装用户相关的 HTTP handlers
 type UserHandler struct {
 	userService service.UserService
@@ -20,22 +47,28 @@ func NewUserHandler(userService service.UserService) *UserHandler {
 	return &UserHandler{userService: userService}
 }
 
-// RegisterRoutes 注册用户相关的 HTTP 路由
-func (h *UserHandler) RegisterRoutes(router *gin.Engine, authMiddleware gin.HandlerFunc) {
+// RegisterRoutes 注册用户相关的 HTTP 路由，写接口支持 Idempotency-Key（idempotencyMiddleware）
+func (h *UserHandler) RegisterRoutes(router *gin.Engine, authMiddleware, idempotencyMiddleware gin.HandlerFunc) {
 	userGroup := router.Group("/api/users")
 	{
-		userGroup.POST("/signup", h.Register)
+		userGroup.POST("/signup", idempotencyMiddleware, h.Register)
 		userGroup.POST("/login", h.Login)
 
 		authedGroup := userGroup.Group("/")
 		authedGroup.Use(authMiddleware)
 		{
 			authedGroup.GET("/:id", h.GetProfile)           // GET /api/users/{id}
-			authedGroup.PUT("/:id", h.UpdateProfile)        // PUT /api/users/{id}
+			authedGroup.PUT("/:id", idempotencyMiddleware, h.UpdateProfile)        // PUT /api/users/{id}
+			authedGroup.PATCH("/:id", idempotencyMiddleware, h.PatchProfile)      // PATCH /api/users/{id}
 			authedGroup.GET("/:id/addresses", h.ListAddresses) // GET /api/users/{id}/addresses
-			authedGroup.POST("/:id/addresses", h.AddAddress) // POST /api/users/{id}/addresses
-			authedGroup.PUT("/:id/addresses/:addrId", h.UpdateAddress) // PUT /api/users/{id}/addresses/{addrId}
-			authedGroup.DELETE("/:id/addresses/:addrId", h.DeleteAddress) // DELETE /api/users/{id}/addresses/{addrId}
+			authedGroup.GET("/:id/addresses/:addrId", h.GetAddress) // GET /api/users/{id}/addresses/{addrId}
+			authedGroup.POST("/:id/addresses", idempotencyMiddleware, h.AddAddress) // POST /api/users/{id}/addresses
+			authedGroup.PUT("/:id/addresses/:addrId", idempotencyMiddleware, h.UpdateAddress) // PUT /api/users/{id}/addresses/{addrId}
+			authedGroup.PATCH("/:id/addresses/:addrId", idempotencyMiddleware, h.PatchAddress) // PATCH /api/users/{id}/addresses/{addrId}
+			authedGroup.DELETE("/:id/addresses/:addrId", idempotencyMiddleware, h.DeleteAddress) // DELETE /api/users/{id}/addresses/{addrId}
+			authedGroup.POST("/:id/addresses/:addrId/default", idempotencyMiddleware, h.SetDefaultAddress) // POST /api/users/{id}/addresses/{addrId}/default
+			authedGroup.POST("/:id/addresses/:addrId/merge", idempotencyMiddleware, h.MergeAddresses) // POST /api/users/{id}/addresses/{addrId}/merge
+			authedGroup.GET("/:id/login-history", h.GetLoginHistory) // GET /api/users/{id}/login-history
 		}
 	}
 }
@@ -55,7 +88,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 	user, err := h.userService.Register(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
 		// 根据错误类型返回不同的状态码
-		if err.Error() == "email already exists" {
+		if errors.Is(err, service.ErrEmailExists) {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user", "details": err.Error()})
@@ -88,6 +121,8 @@ func (h *UserHandler) Login(c *gin.Context) {
 	if err != nil {
 		if err.Error() == "invalid email or password" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		} else if errors.Is(err, service.ErrUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login", "details": err.Error()})
 		}
@@ -110,14 +145,17 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 
 	user, err := h.userService.GetUserProfile(c.Request.Context(), userID)
 	if err != nil {
-		if err.Error() == "user not found" {
+		if errors.Is(err, service.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if errors.Is(err, service.ErrUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user profile", "details": err.Error()})
 		}
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(http.StatusOK, user)
 }
 
@@ -142,19 +180,74 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.UpdateUserProfile(c.Request.Context(), userID, req.FirstName, req.LastName)
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.UpdateUserProfile(c.Request.Context(), userID, ifVersion, req.FirstName, req.LastName)
 	if err != nil {
-		if err.Error() == "user not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile", "details": err.Error()})
-		}
+		respondProfileUpdateError(c, err)
+		return
+	}
+
+	setETag(c, user.Version)
+	c.JSON(http.StatusOK, user)
+}
+
+// PatchProfile 按 JSON Merge Patch（RFC 7396）部分更新用户资料，只修改请求中出现的字段
+func (h *UserHandler) PatchProfile(c *gin.Context) {
+	userID, err := getUserIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	if !checkPermissions(c, userID) {
+		return
+	}
+
+	doc, ok := bindMergePatch(c, "first_name", "last_name")
+	if !ok {
+		return
+	}
+	patch := service.ProfilePatch{
+		FirstName: doc.string("first_name"),
+		LastName:  doc.string("last_name"),
+	}
+	if !doc.valid(c) {
+		return
+	}
+
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
+	user, err := h.userService.PatchUserProfile(c.Request.Context(), userID, ifVersion, patch)
+	if err != nil {
+		respondProfileUpdateError(c, err)
+		return
+	}
+
+	setETag(c, user.Version)
 	c.JSON(http.StatusOK, user)
 }
 
+// respondProfileUpdateError 根据更新资料的错误类型返回不同的状态码
+func respondProfileUpdateError(c *gin.Context, err error) {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondInvalidInput(c, validationErr)
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrVersionMismatch):
+		versionMismatch(c, err)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile", "details": err.Error()})
+	}
+}
+
 // ListAddresses 获取用户地址列表
 func (h *UserHandler) ListAddresses(c *gin.Context) {
 	userID, err := getUserIDFromParam(c)
@@ -166,13 +259,22 @@ func (h *UserHandler) ListAddresses(c *gin.Context) {
         return
     }
 
+	format, ok := addressFormatFromQuery(c)
+	if !ok {
+		return
+	}
+
 	addresses, err := h.userService.GetUserAddresses(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list addresses", "details": err.Error()})
+		if errors.Is(err, service.ErrUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list addresses", "details": err.Error()})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, addresses)
+	c.JSON(http.StatusOK, format.renderAll(addresses))
 }
 
 // AddAddress 添加用户地址
@@ -186,19 +288,74 @@ func (h *UserHandler) AddAddress(c *gin.Context) {
         return
     }
 
-	var req model.Address
+	format, ok := addressFormatFromQuery(c)
+	if !ok {
+		return
+	}
+
+	var req addressRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
 		return
 	}
 
-	address, err := h.userService.AddUserAddress(c.Request.Context(), userID, req)
+	address, err := h.userService.AddUserAddress(c.Request.Context(), userID, req.toModel())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add address", "details": err.Error()})
+		var validationErr *service.ValidationError
+		var duplicate *service.DuplicateAddressError
+		if errors.As(err, &validationErr) {
+			respondInvalidInput(c, validationErr)
+		} else if errors.As(err, &duplicate) {
+			respondDuplicateAddress(c, duplicate, format)
+		} else if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if errors.Is(err, service.ErrAddressLimitReached) {
+			// 与重复地址的 409 区分开，code 与 gRPC PreconditionFailure 的类型一致
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "ADDRESS_LIMIT"})
+		} else if errors.Is(err, service.ErrVersionMismatch) {
+			versionMismatch(c, err)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add address", "details": err.Error()})
+		}
 		return
 	}
 
-	c.JSON(http.StatusCreated, address)
+	setETag(c, address.Version)
+	c.JSON(http.StatusCreated, format.render(address))
+}
+
+// GetAddress 获取用户的单个地址，ETag 为地址的版本
+func (h *UserHandler) GetAddress(c *gin.Context) {
+	userID, err := getUserIDFromParam(c)
+	if err != nil {
+		return
+	}
+	addrID, err := getAddressIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	if !checkPermissions(c, userID) {
+		return
+	}
+
+	format, ok := addressFormatFromQuery(c)
+	if !ok {
+		return
+	}
+
+	address, err := h.userService.GetUserAddress(c.Request.Context(), userID, addrID)
+	if err != nil {
+		if errors.Is(err, service.ErrAddressNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get address", "details": err.Error()})
+		}
+		return
+	}
+
+	setETag(c, address.Version)
+	c.JSON(http.StatusOK, format.render(address))
 }
 
 // UpdateAddress 更新用户地址
@@ -216,23 +373,194 @@ func (h *UserHandler) UpdateAddress(c *gin.Context) {
         return
     }
 
-	var req model.Address
+	format, ok := addressFormatFromQuery(c)
+	if !ok {
+		return
+	}
+
+	var req addressRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
 		return
 	}
 
-	address, err := h.userService.UpdateUserAddress(c.Request.Context(), userID, addrID, req)
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	address, err := h.userService.UpdateUserAddress(c.Request.Context(), userID, addrID, ifVersion, req.toModel())
 	if err != nil {
-		if err.Error() == "address not found or does not belong to user" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update address", "details": err.Error()})
+		respondAddressUpdateError(c, err)
+		return
+	}
+
+	setETag(c, address.Version)
+	c.JSON(http.StatusOK, format.render(address))
+}
+
+// PatchAddress 按 JSON Merge Patch（RFC 7396）部分更新用户地址，只修改请求中出现的字段
+func (h *UserHandler) PatchAddress(c *gin.Context) {
+	userID, err := getUserIDFromParam(c)
+	if err != nil {
+		return
+	}
+	addrID, err := getAddressIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	if !checkPermissions(c, userID) {
+		return
+	}
+
+	format, ok := addressFormatFromQuery(c)
+	if !ok {
+		return
+	}
+
+	doc, ok := bindMergePatch(c, "street", "city", "state", "postal_code", "country", "is_default", "type", "label")
+	if !ok {
+		return
+	}
+	patch := service.AddressPatch{
+		Street:     doc.string("street"),
+		City:       doc.string("city"),
+		State:      doc.string("state"),
+		PostalCode: doc.string("postal_code"),
+		Country:    doc.string("country"),
+		IsDefault:  doc.bool("is_default"),
+		Type:       doc.string("type"),
+		Label:      doc.string("label"),
+	}
+	if !doc.valid(c) {
+		return
+	}
+
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	address, err := h.userService.PatchUserAddress(c.Request.Context(), userID, addrID, ifVersion, patch)
+	if err != nil {
+		respondAddressUpdateError(c, err)
+		return
+	}
+
+	setETag(c, address.Version)
+	c.JSON(http.StatusOK, format.render(address))
+}
+
+// SetDefaultAddress 把地址设为同类型的默认地址，原默认地址同时取消默认
+func (h *UserHandler) SetDefaultAddress(c *gin.Context) {
+	userID, err := getUserIDFromParam(c)
+	if err != nil {
+		return
+	}
+	addrID, err := getAddressIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	if !checkPermissions(c, userID) {
+		return
+	}
+
+	format, ok := addressFormatFromQuery(c)
+	if !ok {
+		return
+	}
+
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	address, err := h.userService.SetDefaultAddress(c.Request.Context(), userID, addrID, ifVersion)
+	if err != nil {
+		respondAddressUpdateError(c, err)
+		return
+	}
+
+	setETag(c, address.Version)
+	c.JSON(http.StatusOK, format.render(address))
+}
+
+// respondDuplicateAddress 响应与已有地址重复的新地址：已有地址包含新地址的全部内容时返回 200 和已有地址，
+// 否则返回 409，Location 和 existing_address_id 指向已有地址
+func respondDuplicateAddress(c *gin.Context, duplicate *service.DuplicateAddressError, format addressFormat) {
+	existing := &duplicate.Existing
+	if duplicate.Exact {
+		setETag(c, existing.Version)
+		c.JSON(http.StatusOK, format.render(existing))
+		return
+	}
+	c.Header("Location", fmt.Sprintf("/api/users/%d/addresses/%d", existing.UserID, existing.ID))
+	c.JSON(http.StatusConflict, gin.H{"error": duplicate.Error(), "existing_address_id": existing.ID})
+}
+
+// MergeAddresses 把重复的地址合并到 addrId：请求体的 address_ids 列出要合并的地址，为空时合并 addrId 的全部重复地址
+func (h *UserHandler) MergeAddresses(c *gin.Context) {
+	userID, err := getUserIDFromParam(c)
+	if err != nil {
+		return
+	}
+	addrID, err := getAddressIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	if !checkPermissions(c, userID) {
+		return
+	}
+
+	format, ok := addressFormatFromQuery(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		AddressIDs []uint `json:"address_ids"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+			return
 		}
+	}
+
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	address, mergedIDs, err := h.userService.MergeUserAddresses(c.Request.Context(), userID, addrID, ifVersion, req.AddressIDs)
+	if err != nil {
+		respondAddressUpdateError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, address)
+	if mergedIDs == nil {
+		mergedIDs = []uint{}
+	}
+	setETag(c, address.Version)
+	c.JSON(http.StatusOK, gin.H{"address": format.render(address), "merged_address_ids": mergedIDs})
+}
+
+// respondAddressUpdateError 根据更新地址的错误类型返回不同的状态码
+func respondAddressUpdateError(c *gin.Context, err error) {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondInvalidInput(c, validationErr)
+	case errors.Is(err, service.ErrAddressNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrVersionMismatch):
+		versionMismatch(c, err)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update address", "details": err.Error()})
+	}
 }
 
 // DeleteAddress 删除用户地址
@@ -250,10 +578,17 @@ func (h *UserHandler) DeleteAddress(c *gin.Context) {
         return
     }
 
-	err = h.userService.DeleteUserAddress(c.Request.Context(), userID, addrID)
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	err = h.userService.DeleteUserAddress(c.Request.Context(), userID, addrID, ifVersion)
 	if err != nil {
-        if err.Error() == "address not found or does not belong to user" {
+        if errors.Is(err, service.ErrAddressNotFound) {
             c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else if errors.Is(err, service.ErrVersionMismatch) {
+            versionMismatch(c, err)
         } else {
             c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete address", "details": err.Error()})
         }
@@ -263,6 +598,32 @@ func (h *UserHandler) DeleteAddress(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// GetLoginHistory 获取用户最近的登录记录
+func (h *UserHandler) GetLoginHistory(c *gin.Context) {
+	userID, err := getUserIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	if !checkPermissions(c, userID) {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit, must be between 1 and 100"})
+		return
+	}
+
+	history, err := h.userService.GetLoginHistory(c.Request.Context(), userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get login history", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
 // Helper function to get user ID from URL param
 func getUserIDFromParam(c *gin.Context) (uint, error) {
 	userIDStr := c.Param("id")
@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/blackwatch66/user-microservice/internal/service"
+	"github.com/blackwatch66/user-microservice/internal/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler 封装 webhook 订阅管理相关的 HTTP handlers（仅限管理员）
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookHandler 创建一个新的 WebhookHandler
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// RegisterRoutes 注册 webhook 管理路由
+func (h *WebhookHandler) RegisterRoutes(router *gin.Engine, adminMiddleware gin.HandlerFunc) {
+	webhookGroup := router.Group("/api/admin/webhooks")
+	webhookGroup.Use(adminMiddleware)
+	{
+		webhookGroup.POST("", h.Create)                                         // POST /api/admin/webhooks
+		webhookGroup.GET("", h.List)                                            // GET /api/admin/webhooks
+		webhookGroup.GET("/:id", h.Get)                                         // GET /api/admin/webhooks/{id}
+		webhookGroup.PUT("/:id", h.Update)                                      // PUT /api/admin/webhooks/{id}
+		webhookGroup.DELETE("/:id", h.Delete)                                   // DELETE /api/admin/webhooks/{id}
+		webhookGroup.GET("/:id/deliveries", h.ListDeliveries)                   // GET /api/admin/webhooks/{id}/deliveries
+		webhookGroup.GET("/:id/deliveries/:deliveryId", h.GetDelivery)          // GET /api/admin/webhooks/{id}/deliveries/{deliveryId}
+		webhookGroup.POST("/:id/deliveries/:deliveryId/redeliver", h.Redeliver) // POST /api/admin/webhooks/{id}/deliveries/{deliveryId}/redeliver
+	}
+}
+
+// webhookRequest 创建与更新订阅的请求体
+type webhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	EventTypes  []string `json:"event_types" binding:"required"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+	Secret      string   `json:"secret"`
+}
+
+func (r webhookRequest) toInput() service.WebhookInput {
+	return service.WebhookInput{
+		URL:         r.URL,
+		EventTypes:  r.EventTypes,
+		Description: r.Description,
+		Active:      r.Active,
+		Secret:      r.Secret,
+	}
+}
+
+// webhookResponse 订阅的响应格式，密钥只在创建时返回
+type webhookResponse struct {
+	ID          uint      `json:"id"`
+	URL         string    `json:"url"`
+	EventTypes  []string  `json:"event_types"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func newWebhookResponse(sub *model.WebhookSubscription) webhookResponse {
+	return webhookResponse{
+		ID:          sub.ID,
+		URL:         sub.URL,
+		EventTypes:  webhook.SplitEventTypes(sub.EventTypes),
+		Description: sub.Description,
+		Active:      sub.Active,
+		CreatedAt:   sub.CreatedAt,
+		UpdatedAt:   sub.UpdatedAt,
+	}
+}
+
+// deliveryResponse 投递记录的响应格式
+type deliveryResponse struct {
+	ID             uint64     `json:"id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func newDeliveryResponse(d *model.WebhookDelivery) deliveryResponse {
+	resp := deliveryResponse{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+	}
+	if d.Status == model.WebhookDeliveryPending {
+		resp.NextAttemptAt = &d.NextAttemptAt
+	}
+	return resp
+}
+
+// Create 创建订阅，响应中包含签名密钥
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	sub, err := h.webhookService.CreateSubscription(c.Request.Context(), req.toInput())
+	if err != nil {
+		respondWebhookError(c, err, "Failed to create webhook subscription")
+		return
+	}
+
+	resp := newWebhookResponse(sub)
+	resp.Secret = sub.Secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+// List 列出所有订阅
+func (h *WebhookHandler) List(c *gin.Context) {
+	subs, err := h.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		respondWebhookError(c, err, "Failed to list webhook subscriptions")
+		return
+	}
+
+	resp := make([]webhookResponse, 0, len(subs))
+	for i := range subs {
+		resp = append(resp, newWebhookResponse(&subs[i]))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Get 获取单个订阅
+func (h *WebhookHandler) Get(c *gin.Context) {
+	id, err := getWebhookIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	sub, err := h.webhookService.GetSubscription(c.Request.Context(), id)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to get webhook subscription")
+		return
+	}
+	c.JSON(http.StatusOK, newWebhookResponse(sub))
+}
+
+// Update 更新订阅
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := getWebhookIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	sub, err := h.webhookService.UpdateSubscription(c.Request.Context(), id, req.toInput())
+	if err != nil {
+		respondWebhookError(c, err, "Failed to update webhook subscription")
+		return
+	}
+	c.JSON(http.StatusOK, newWebhookResponse(sub))
+}
+
+// Delete 删除订阅及其投递记录
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := getWebhookIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(c.Request.Context(), id); err != nil {
+		respondWebhookError(c, err, "Failed to delete webhook subscription")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries 列出订阅最近的投递记录，可按 status 过滤
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, err := getWebhookIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", model.WebhookDeliveryPending, model.WebhookDeliverySucceeded, model.WebhookDeliveryDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, must be pending, succeeded or dead"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit, must be between 1 and 500"})
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), id, status, limit)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to list webhook deliveries")
+		return
+	}
+
+	resp := make([]deliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		resp = append(resp, newDeliveryResponse(&deliveries[i]))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetDelivery 获取投递详情，包括请求体与每次尝试的结果
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, err := getWebhookIDFromParam(c)
+	if err != nil {
+		return
+	}
+	deliveryID, err := getDeliveryIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	delivery, attempts, err := h.webhookService.GetDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to get webhook delivery")
+		return
+	}
+
+	attemptList := make([]gin.H, 0, len(attempts))
+	for _, attempt := range attempts {
+		attemptList = append(attemptList, gin.H{
+			"status_code":   attempt.StatusCode,
+			"duration_ms":   attempt.DurationMs,
+			"error":         attempt.Error,
+			"response_body": attempt.ResponseBody,
+			"attempted_at":  attempt.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"delivery": newDeliveryResponse(delivery),
+		"payload":  delivery.Payload,
+		"attempts": attemptList,
+	})
+}
+
+// Redeliver 手动重新投递（包括已进入 dead 状态的投递）
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := getWebhookIDFromParam(c)
+	if err != nil {
+		return
+	}
+	deliveryID, err := getDeliveryIDFromParam(c)
+	if err != nil {
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		respondWebhookError(c, err, "Failed to schedule redelivery")
+		return
+	}
+	c.JSON(http.StatusAccepted, newDeliveryResponse(delivery))
+}
+
+// respondWebhookError 根据错误类型返回不同的状态码
+func respondWebhookError(c *gin.Context, err error, message string) {
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+	case errors.Is(err, service.ErrWebhookNotFound), errors.Is(err, service.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// Helper function to get webhook subscription ID from URL param
+func getWebhookIDFromParam(c *gin.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID format"})
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// Helper function to get delivery ID from URL param
+func getDeliveryIDFromParam(c *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param("deliveryId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID format"})
+		return 0, err
+	}
+	return id, nil
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminKeyHeader 管理接口使用的 API Key 请求头
+const AdminKeyHeader = "X-Admin-Key"
+
+// AdminMiddleware 创建一个 Gin 中间件，使用共享的 API Key 保护管理接口
+func AdminMiddleware(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 使用常量时间比较，避免通过响应时间推测 Key
+		provided := c.GetHeader(AdminKeyHeader)
+		if apiKey == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey)) != 1 {
+			authLog.InfoContext(c.Request.Context(), "Admin authentication failed", "path", c.FullPath())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing admin API key"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"github.com/blackwatch66/user-microservice/internal/audit"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditContextMiddleware 将客户端 IP 与 User-Agent 写入请求上下文，供审计日志使用
+func AuditContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(audit.WithClient(c.Request.Context(), c.ClientIP(), c.Request.UserAgent()))
+		c.Next()
+	}
+}
@@ -1,12 +1,16 @@
 package middleware
 
 import (
-	"log"
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
-	"github.com/blackwatch66/user-microservice/config"
+	"github.com/blackwatch66/user-microservice/internal/audit"
 	"github.com/blackwatch66/user-microservice/internal/auth"
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"github.com/blackwatch66/user-microservice/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
@@ -16,12 +20,18 @@ const (
     UserContextKey         = "userClaims" // Context key to store user claims
 )
 
+var authLog = logger.For("auth")
+
+// TokenValidator 校验 token 签名并检查是否已被吊销，由 service.UserService 实现
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, tokenString string) (*auth.Claims, error)
+}
+
 // AuthMiddleware 创建一个 Gin 中间件用于 JWT 认证
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+func AuthMiddleware(validator TokenValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader(AuthorizationHeaderKey)
-		log.Printf("Received Authorization header: %s", authHeader)
-		
+
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
 			return
@@ -41,21 +51,24 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			// 没有Bearer前缀，假设整个值就是token
 			tokenString = authHeader
 		}
-		
-		log.Printf("Extracted token: %s", tokenString)
 
-		claims, err := auth.ValidateJWT(tokenString, cfg.JWTSecret)
+		claims, err := validator.ValidateToken(c.Request.Context(), tokenString)
+		metrics.ObserveTokenValidation("http", err)
+		if errors.Is(err, service.ErrUnavailable) {
+			// REDIS_POLICY_REVOCATION=fail_closed 且无法检查吊销状态
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service temporarily unavailable"})
+			return
+		}
 		if err != nil {
+			authLog.InfoContext(c.Request.Context(), "Token validation failed", "error", err)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token", "details": err.Error()})
 			return
 		}
 
-        // 可选：检查 Redis 中的 token 标识符
-        // ... (类似 service 中的 ValidateToken 逻辑)
-
 		// 将用户信息存入 Gin 的 Context 中，方便后续 Handler 使用
 		c.Set(UserContextKey, claims)
-		log.Printf("Authentication successful for user ID: %d", claims.UserID)
+		c.Request = c.Request.WithContext(audit.WithUserID(c.Request.Context(), claims.UserID))
+		authLog.DebugContext(c.Request.Context(), "Authentication successful", "user_id", claims.UserID)
 
 		c.Next()
 	}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/blackwatch66/user-microservice/internal/idempotency"
+	"github.com/blackwatch66/user-microservice/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader 客户端为可重试的写请求设置的幂等键
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader 响应是首次请求结果的重放时设置为 true
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotentBody 参与幂等指纹计算的请求体上限
+const maxIdempotentBody = 1 << 20
+
+// replayedHeaders 除 Content-Type 外随响应体一起保存并重放的响应头
+var replayedHeaders = []string{"ETag", "Location"}
+
+// idempotencyRecorder 在写出响应的同时记录响应体
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware 创建一个 Gin 中间件：携带 Idempotency-Key 的请求只处理一次，
+// 相同键、相同请求的重试直接重放首次的响应；键相同但请求不同返回 422，首次请求尚未完成时返回 409。
+// 应放在认证中间件之后，键按用户隔离；未认证的接口（如注册）共享一个匿名作用域。
+func IdempotencyMiddleware(guard *idempotency.Guard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large for an idempotent request"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := "http:anonymous"
+		if claims, ok := GetUserClaims(c); ok {
+			scope = "http:user:" + strconv.FormatUint(uint64(claims.UserID), 10)
+		}
+		// If-Match 和 Content-Type 会改变请求的处理结果，同样计入指纹
+		fingerprint := idempotency.Fingerprint([]byte(c.Request.Method), []byte(c.Request.URL.RequestURI()),
+			[]byte(c.GetHeader("If-Match")), []byte(c.GetHeader("Content-Type")), body)
+
+		ctx := c.Request.Context()
+		attempt, err := guard.Begin(ctx, scope, key, fingerprint)
+		switch {
+		case errors.Is(err, idempotency.ErrInvalidKey):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be 1 to 255 printable ASCII characters"})
+			return
+		case errors.Is(err, idempotency.ErrMismatch):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, idempotency.ErrInProgress):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, idempotency.ErrUnavailable):
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "service temporarily unavailable"})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if replay := attempt.Replay; replay != nil {
+			c.Header(IdempotentReplayedHeader, "true")
+			for name, value := range replay.Headers {
+				c.Header(name, value)
+			}
+			c.Data(replay.Status, replay.ContentType, replay.Body)
+			c.Abort()
+			return
+		}
+
+		// handler panic 时释放键，否则重试会在键过期前一直得到 409
+		defer attempt.Release(ctx)
+		recorder := &idempotencyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// 5xx 可能是暂时性故障，不保存结果，允许客户端用同一个键重试
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		var headers map[string]string
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				if headers == nil {
+					headers = make(map[string]string, len(replayedHeaders))
+				}
+				headers[name] = value
+			}
+		}
+		attempt.Complete(ctx, &repository.IdempotencyRecord{
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Headers:     headers,
+			Body:        recorder.body.Bytes(),
+		})
+	}
+}
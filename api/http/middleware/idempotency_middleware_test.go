@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/idempotency"
+	"github.com/blackwatch66/user-microservice/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const (
+		firstBody    = `{"street":"1 Main St"}`
+		firstIfMatch = `"3"`
+	)
+
+	tests := []struct {
+		name string
+		// inProgress reserves the key for the first request without completing it
+		inProgress   bool
+		retryBody    string
+		retryIfMatch string
+		wantStatus   int
+		wantReplayed bool
+		wantCalls    int
+	}{
+		{"identical retry replays", false, firstBody, firstIfMatch, http.StatusCreated, true, 1},
+		{"different body", false, `{"street":"2 Main St"}`, firstIfMatch, http.StatusUnprocessableEntity, false, 1},
+		{"different If-Match", false, firstBody, `"4"`, http.StatusUnprocessableEntity, false, 1},
+		{"retry while in progress", true, firstBody, firstIfMatch, http.StatusConflict, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := idempotency.NewGuard(repository.NewMemoryIdempotencyStore(), time.Hour, false)
+			calls := 0
+			router := gin.New()
+			router.POST("/addresses", IdempotencyMiddleware(guard), func(c *gin.Context) {
+				calls++
+				c.Header("ETag", `"`+strconv.Itoa(calls)+`"`)
+				c.Header("Location", "/addresses/"+strconv.Itoa(calls))
+				c.JSON(http.StatusCreated, gin.H{"id": calls})
+			})
+			send := func(body, ifMatch string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/addresses", strings.NewReader(body))
+				req.Header.Set(IdempotencyKeyHeader, "key-1")
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("If-Match", ifMatch)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				return w
+			}
+
+			var first *httptest.ResponseRecorder
+			if tt.inProgress {
+				fingerprint := idempotency.Fingerprint([]byte(http.MethodPost), []byte("/addresses"),
+					[]byte(firstIfMatch), []byte("application/json"), []byte(firstBody))
+				if _, err := guard.Begin(context.Background(), "http:anonymous", "key-1", fingerprint); err != nil {
+					t.Fatal(err)
+				}
+			} else if first = send(firstBody, firstIfMatch); first.Code != http.StatusCreated {
+				t.Fatalf("first request = %d, want 201", first.Code)
+			}
+
+			retry := send(tt.retryBody, tt.retryIfMatch)
+			if retry.Code != tt.wantStatus {
+				t.Fatalf("retry = %d %s, want %d", retry.Code, retry.Body, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+			if replayed := retry.Header().Get(IdempotentReplayedHeader) == "true"; replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed {
+				for _, header := range []string{"Content-Type", "ETag", "Location"} {
+					if got, want := retry.Header().Get(header), first.Header().Get(header); got != want {
+						t.Errorf("replayed %s = %q, want %q", header, got, want)
+					}
+				}
+				if retry.Body.String() != first.Body.String() {
+					t.Errorf("replayed body = %s, want %s", retry.Body, first.Body)
+				}
+			}
+		})
+	}
+}
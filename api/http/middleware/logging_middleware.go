@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+var httpLog = logger.For("http")
+
+// RequestIDMiddleware 读取或生成请求 ID，写入请求上下文与响应头，应作为第一个中间件注册
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(logger.RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = logger.NewRequestID()
+		}
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Header(logger.RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// AccessLogMiddleware 以结构化日志记录每个请求，不记录请求头与请求体，避免泄露凭证
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "error", c.Errors.String())
+		}
+		httpLog.Log(c.Request.Context(), level, "HTTP request handled", attrs...)
+	}
+}
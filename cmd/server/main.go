@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
@@ -16,41 +16,145 @@ import (
 	httpMiddleware "github.com/blackwatch66/user-microservice/api/http/middleware"
 	"github.com/blackwatch66/user-microservice/config"
 	"github.com/blackwatch66/user-microservice/internal/database"
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/geoip"
+	"github.com/blackwatch66/user-microservice/internal/health"
+	"github.com/blackwatch66/user-microservice/internal/idempotency"
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"github.com/blackwatch66/user-microservice/internal/outbox"
 	"github.com/blackwatch66/user-microservice/internal/redis"
+	"github.com/blackwatch66/user-microservice/internal/repository"
 	"github.com/blackwatch66/user-microservice/internal/service"
+	"github.com/blackwatch66/user-microservice/internal/tracing"
+	"github.com/blackwatch66/user-microservice/internal/webhook"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	channelzService "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/reflection"
+	"gorm.io/gorm"
 )
 
 func main() {
+	// migrate 子命令只需要 DATABASE_URL，不启动服务
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
 	// 加载配置
 	cfg := config.LoadConfig()
 
+	// 初始化结构化日志
+	if err := logger.Init(os.Stdout, cfg.LogLevel, cfg.LogComponentLevels, cfg.LogFormat); err != nil {
+		fatal("Failed to initialize logger", err)
+	}
+
+	// 初始化 OpenTelemetry 链路追踪
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.TracesExporter, cfg.TracesFilePath)
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
+	}
+
 	// 初始化数据库
-	db, err := database.InitDatabase(cfg.DatabaseURL)
+	db, err := database.InitDatabase(cfg.DatabaseURL, cfg.DBSlowQueryThreshold, cfg.DBAutoMigrate)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("Failed to initialize database", err)
+	}
+
+	// 连接只读副本（可选），能容忍复制延迟的读取会路由到副本
+	var replicas *database.ReplicaSet
+	if len(cfg.DatabaseReplicaURLs) > 0 {
+		replicas, err = database.OpenReplicas(db, cfg.DatabaseReplicaURLs, cfg.DBSlowQueryThreshold)
+		if err != nil {
+			fatal("Failed to initialize database replicas", err)
+		}
+		defer replicas.Close()
 	}
 
 	// 初始化 Redis
-	rdb, err := redis.InitRedis(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+	rdb, err := redis.NewClient(context.Background(), cfg.Redis)
 	if err != nil {
-		log.Fatalf("Failed to initialize redis: %v", err)
+		fatal("Failed to initialize redis", err)
 	}
+	defer rdb.Close()
+
+	// 初始化依赖健康检查
+	healthChecker := health.NewChecker(cfg.HealthCheckTimeout)
+	healthChecker.Register(db.Dialector.Name(), health.DatabaseCheck(db)) // mysql, postgres 或 sqlite
+	// Redis 不可用时服务降级运行，readiness 中列出各功能的降级策略
+	redisDegraded := map[string]string{"sessions": cfg.SessionsRedisPolicy, "token_revocation": cfg.RevocationRedisPolicy}
+	if cfg.ProfileCacheTTL > 0 {
+		redisDegraded["profile_cache"] = cfg.CacheRedisPolicy
+	}
+	redisDegraded["idempotency"] = cfg.IdempotencyRedisPolicy
+	if cfg.EventPublisher == events.PublisherRedis {
+		redisDegraded["event_publishing"] = "retry" // outbox relay 会重试，事件不会丢失
+	}
+	healthChecker.RegisterOptional("redis", rdb.Check, redisDegraded)
+
+	// 加载离线 GeoIP 数据库，用于登录历史中的粗略位置
+	geoLocator, err := geoip.Open(cfg.GeoIPDatabasePath)
+	if err != nil {
+		fatal("Failed to load GeoIP database", err)
+	}
+	defer geoLocator.Close()
 
 	// 初始化 Service
-	userService := service.NewUserService(db, rdb, cfg)
+	var readDB *gorm.DB
+	if replicas != nil {
+		readDB = replicas.DB()
+	}
+	// 资料与地址的读穿缓存，TTL 为 0 时关闭
+	var profileCache repository.ProfileCache
+	if cfg.ProfileCacheTTL > 0 {
+		profileCache = repository.NewRedisProfileCache(rdb, cfg.ProfileCacheTTL)
+	}
+	userService := service.NewUserService(repository.NewGormStore(db, readDB), repository.NewRedisSessionStore(rdb), profileCache, cfg, geoLocator)
+
+	// 写请求的幂等键，HTTP 与 gRPC 共用同一个存储
+	idempotencyGuard := idempotency.NewGuard(repository.NewRedisIdempotencyStore(rdb), cfg.IdempotencyTTL, cfg.IdempotencyRedisPolicy == config.PolicyFailClosed)
+
+	// 初始化 Gin Engine，使用结构化访问日志替代 gin 默认的文本日志
+	if cfg.LogLevel != "debug" {
+		gin.SetMode(gin.ReleaseMode) // 关闭 gin 的调试输出（路由表等）
+	}
+	router := gin.New()
+	router.Use(
+		httpMiddleware.RequestIDMiddleware(),
+		httpMiddleware.AuditContextMiddleware(),
+		httpMiddleware.TracingMiddleware(),
+		httpMiddleware.MetricsMiddleware(),
+		httpMiddleware.AccessLogMiddleware(),
+		gin.Recovery(),
+	)
 
-	// 初始化 Gin Engine
-	router := gin.Default()
+	// 暴露 Prometheus 指标
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// 设置 JWT 中间件
-	authMiddleware := httpMiddleware.AuthMiddleware(cfg)
+	authMiddleware := httpMiddleware.AuthMiddleware(userService)
 
 	// 初始化 HTTP Handler 并注册路由
 	userHttpHandler := httpHandler.NewUserHandler(userService)
-	userHttpHandler.RegisterRoutes(router, authMiddleware)
+	userHttpHandler.RegisterRoutes(router, authMiddleware, httpMiddleware.IdempotencyMiddleware(idempotencyGuard))
+
+	// 注册管理接口（需要 ADMIN_API_KEY）
+	if cfg.AdminAPIKey != "" {
+		adminMiddleware := httpMiddleware.AdminMiddleware(cfg.AdminAPIKey)
+		webhookHttpHandler := httpHandler.NewWebhookHandler(service.NewWebhookService(db))
+		webhookHttpHandler.RegisterRoutes(router, adminMiddleware)
+		auditHttpHandler := httpHandler.NewAuditHandler(service.NewAuditService(db))
+		auditHttpHandler.RegisterRoutes(router, adminMiddleware)
+	} else {
+		slog.Warn("ADMIN_API_KEY is not set, admin API is disabled")
+	}
+
+	// 注册存活与就绪探针
+	healthHttpHandler := httpHandler.NewHealthHandler(healthChecker)
+	healthHttpHandler.RegisterRoutes(router)
 
 	// 创建 HTTP Server
 	httpServer := &http.Server{
@@ -59,21 +163,113 @@ func main() {
 	}
 
 	// 创建 gRPC Server
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))),
+		grpc.ChainUnaryInterceptor(
+			grpcApi.MetricsUnaryInterceptor(),
+			grpcApi.LoggingUnaryInterceptor(),
+			grpcApi.IdempotencyUnaryInterceptor(idempotencyGuard,
+				pb.UserService_CreateUser_FullMethodName,
+				pb.UserService_UpdateUserProfile_FullMethodName,
+				pb.UserService_AddAddress_FullMethodName,
+				pb.UserService_UpdateAddress_FullMethodName,
+				pb.UserService_DeleteAddress_FullMethodName,
+				pb.UserService_SetDefaultAddress_FullMethodName,
+				pb.UserService_MergeAddresses_FullMethodName,
+			),
+		),
+		grpc.ChainStreamInterceptor(grpcApi.MetricsStreamInterceptor()),
+	)
 	userGrpcServer := grpcApi.NewUserServer(userService)
 	pb.RegisterUserServiceServer(grpcServer, userGrpcServer)
 
+	// 注册标准 grpc.health.v1 健康检查服务
+	var healthReporter *grpcApi.HealthReporter
+	if cfg.GRPCHealthEnabled {
+		healthReporter = grpcApi.NewHealthReporter(healthChecker, cfg.GRPCHealthCheckInterval)
+		healthReporter.Register(grpcServer)
+	}
+
+	// 注册 Server Reflection，便于 grpcurl 等工具调试
+	if cfg.GRPCReflectionEnabled {
+		reflection.Register(grpcServer)
+	}
+
+	// 创建 channelz 管理 Server（可选，使用独立端口）
+	var channelzServer *grpc.Server
+	if cfg.GRPCChannelzPort != "" {
+		channelzServer = grpc.NewServer()
+		channelzService.RegisterChannelzServiceToServer(channelzServer)
+	}
+
 	// 使用 errgroup 管理 goroutines 和错误处理
 	g, ctx := errgroup.WithContext(context.Background())
 
+	// 后台任务使用独立的上下文，在服务器关闭后停止
+	bgCtx, stopBackground := context.WithCancel(ctx)
+	defer stopBackground()
+
+	// 周期性更新 gRPC 健康状态
+	if healthReporter != nil {
+		go healthReporter.Run(bgCtx)
+	}
+
+	// 启动 outbox relay，发布事务中写入的领域事件
+	brokerPublisher, err := events.NewPublisher(cfg, rdb)
+	if err != nil {
+		fatal("Failed to create event publisher", err)
+	}
+	slog.Info("Event publisher configured", "publisher", cfg.EventPublisher, "encoding", cfg.EventEncoding)
+	// webhook 投递排在前面：它是幂等的，broker 发布失败导致的重试不会产生重复投递
+	publishers := []events.EventPublisher{webhook.NewDispatcher(db), brokerPublisher}
+	if profileCache != nil {
+		// 提交后再次失效缓存，清除并发读取在提交前或从滞后副本写入的旧数据
+		publishers = append(publishers, service.NewCacheInvalidator(profileCache))
+	}
+	eventPublisher := events.NewMultiPublisher(publishers...)
+	outboxRelay := outbox.NewRelay(db, eventPublisher, outbox.RelayConfig{
+		PollInterval: cfg.OutboxPollInterval,
+		BatchSize:    cfg.OutboxBatchSize,
+		MaxBackoff:   cfg.OutboxMaxBackoff,
+		Retention:    cfg.OutboxRetention,
+	})
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		outboxRelay.Run(bgCtx)
+	}()
+
+	// Redis 断路器打开期间在后台重连
+	go rdb.Run(bgCtx, cfg.Redis.ReconnectInterval)
+
+	// 定期检查只读副本，剔除不可用的副本
+	if replicas != nil {
+		go replicas.Run(bgCtx, cfg.DBReplicaCheckInterval)
+	}
+
+	// 启动 webhook 投递 worker
+	webhookWorker := webhook.NewWorker(db, webhook.WorkerConfig{
+		PollInterval: cfg.WebhookPollInterval,
+		BatchSize:    cfg.WebhookConcurrency * 10,
+		Concurrency:  cfg.WebhookConcurrency,
+		Timeout:      cfg.WebhookTimeout,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		MaxBackoff:   cfg.WebhookMaxBackoff,
+	})
+	webhookDone := make(chan struct{})
+	go func() {
+		defer close(webhookDone)
+		webhookWorker.Run(bgCtx)
+	}()
+
 	// 启动 HTTP Server
 	g.Go(func() error {
-		log.Printf("Starting HTTP server on port %s\n", cfg.HTTPPort)
+		slog.Info("Starting HTTP server", "port", cfg.HTTPPort)
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("HTTP server ListenAndServe error: %v", err)
+			slog.Error("HTTP server ListenAndServe error", "error", err)
 			return err
 		}
-		log.Println("HTTP server stopped gracefully.")
+		slog.Info("HTTP server stopped gracefully.")
 		return nil
 	})
 
@@ -81,18 +277,36 @@ func main() {
 	g.Go(func() error {
 		lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
 		if err != nil {
-			log.Printf("Failed to listen for gRPC: %v", err)
+			slog.Error("Failed to listen for gRPC", "error", err)
 			return err
 		}
-		log.Printf("Starting gRPC server on port %s\n", cfg.GRPCPort)
+		slog.Info("Starting gRPC server", "port", cfg.GRPCPort)
 		if err := grpcServer.Serve(lis); err != nil {
-			log.Printf("gRPC server Serve error: %v", err)
+			slog.Error("gRPC server Serve error", "error", err)
 			return err
 		}
-		log.Println("gRPC server stopped gracefully.")
+		slog.Info("gRPC server stopped gracefully.")
 		return nil
 	})
 
+	// 启动 channelz 管理 Server
+	if channelzServer != nil {
+		g.Go(func() error {
+			lis, err := net.Listen("tcp", ":"+cfg.GRPCChannelzPort)
+			if err != nil {
+				slog.Error("Failed to listen for channelz", "error", err)
+				return err
+			}
+			slog.Info("Starting channelz admin server", "port", cfg.GRPCChannelzPort)
+			if err := channelzServer.Serve(lis); err != nil {
+				slog.Error("channelz server Serve error", "error", err)
+				return err
+			}
+			slog.Info("channelz server stopped gracefully.")
+			return nil
+		})
+	}
+
 	// 监听退出信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -100,9 +314,19 @@ func main() {
 	// 等待退出信号或 goroutine 错误
 	select {
 	case <-quit:
-		log.Println("Received shutdown signal. Shutting down servers...")
+		slog.Info("Received shutdown signal. Shutting down servers...")
 	case <-ctx.Done():
-		log.Println("Context cancelled. Shutting down servers due to error...")
+		slog.Error("Context cancelled. Shutting down servers due to error...")
+	}
+
+	// 先标记为未就绪，让负载均衡器在服务停止接收请求之前摘除流量
+	healthChecker.MarkShuttingDown()
+	if healthReporter != nil {
+		healthReporter.Shutdown()
+	}
+	if cfg.ShutdownDrainDelay > 0 && ctx.Err() == nil {
+		slog.Info("Marked as not ready, draining before stopping servers...", "drain_delay", cfg.ShutdownDrainDelay.String())
+		time.Sleep(cfg.ShutdownDrainDelay)
 	}
 
 	// 设置超时上下文用于优雅关闭
@@ -111,17 +335,42 @@ func main() {
 
 	// 关闭 HTTP Server
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		log.Printf("HTTP server shutdown error: %v", err)
+		slog.Error("HTTP server shutdown error", "error", err)
 	}
 
 	// 关闭 gRPC Server
 	grpcServer.GracefulStop()
-	log.Println("gRPC server stopped.")
+	slog.Info("gRPC server stopped.")
+
+	// 关闭 channelz 管理 Server
+	if channelzServer != nil {
+		channelzServer.GracefulStop()
+		slog.Info("channelz server stopped.")
+	}
+
+	// 停止后台任务，等待 relay 与 webhook worker 完成当前批次
+	stopBackground()
+	<-relayDone
+	<-webhookDone
+	if err := eventPublisher.Close(); err != nil {
+		slog.Error("Event publisher close error", "error", err)
+	}
 
 	// 等待所有 goroutines 完成
 	if err := g.Wait(); err != nil {
-		log.Printf("Server shutdown completed with error: %v", err)
+		slog.Error("Server shutdown completed with error", "error", err)
 	} else {
-		log.Println("Server shutdown completed successfully.")
+		slog.Info("Server shutdown completed successfully.")
 	}
+
+	// 刷新尚未导出的 span
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Tracing shutdown error", "error", err)
+	}
+}
+
+// fatal 记录错误并退出进程
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
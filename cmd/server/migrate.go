@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/database"
+	"github.com/blackwatch66/user-microservice/internal/logger"
+)
+
+const migrateUsage = `Usage: user-service migrate <command> [argument]
+
+Commands:
+  up [VERSION]     应用待执行的迁移，默认迁移到最新版本
+  down [N]         回滚最近的 N 个迁移，默认 1
+  status           列出所有迁移及其执行时间
+  version          打印当前 schema 版本
+  force VERSION    手动修复失败的迁移后，将 schema 标记为 VERSION 且不执行任何 SQL
+
+The database is selected by the DATABASE_URL environment variable.
+`
+
+// runMigrate 执行 migrate 子命令并返回进程退出码
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	level, format := os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT")
+	if level == "" {
+		level = "info"
+	}
+	if format == "" {
+		format = "text"
+	}
+	if err := logger.Init(os.Stderr, level, os.Getenv("LOG_LEVELS"), format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		fmt.Fprintln(os.Stderr, "DATABASE_URL is not set")
+		return 1
+	}
+	db, err := database.Open(databaseURL, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := migrateCommand(context.Background(), migrator, args[0], args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// migrateCommand 分发 migrate 的各个命令
+func migrateCommand(ctx context.Context, migrator *database.Migrator, command string, args []string) error {
+	switch command {
+	case "up":
+		target, err := optionalUint(args, 0)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up(ctx, uint(target))
+		if err != nil {
+			return err
+		}
+		return printVersion(ctx, migrator, fmt.Sprintf("%d migration(s) applied", applied))
+	case "down":
+		steps, err := optionalUint(args, 1)
+		if err != nil {
+			return err
+		}
+		reverted, err := migrator.Down(ctx, int(steps))
+		if err != nil {
+			return err
+		}
+		return printVersion(ctx, migrator, fmt.Sprintf("%d migration(s) reverted", reverted))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Dirty {
+				applied += " (dirty)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return w.Flush()
+	case "version":
+		return printVersion(ctx, migrator, "")
+	case "force":
+		if len(args) != 1 {
+			return fmt.Errorf("force requires a VERSION argument")
+		}
+		version, err := optionalUint(args, 0)
+		if err != nil {
+			return err
+		}
+		if err := migrator.Force(ctx, uint(version)); err != nil {
+			return err
+		}
+		return printVersion(ctx, migrator, "")
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", command, migrateUsage)
+	}
+}
+
+// printVersion 打印当前 schema 版本，prefix 非空时作为前缀
+func printVersion(ctx context.Context, migrator *database.Migrator, prefix string) error {
+	version, dirty, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	line := fmt.Sprintf("schema version %d (latest %d)", version, migrator.Latest())
+	if dirty {
+		line += ", dirty"
+	}
+	if prefix != "" {
+		line = prefix + ", " + line
+	}
+	fmt.Println(line)
+	return nil
+}
+
+// optionalUint 解析第一个参数，缺省时返回 fallback
+func optionalUint(args []string, fallback uint64) (uint64, error) {
+	if len(args) == 0 {
+		return fallback, nil
+	}
+	if len(args) > 1 {
+		return 0, fmt.Errorf("unexpected arguments %v", args[1:])
+	}
+	value, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", args[0])
+	}
+	return value, nil
+}
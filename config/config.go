@@ -1,58 +1,245 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config stores all application configurations
 type Config struct {
-	HTTPPort     string
-	GRPCPort     string
-	DatabaseURL  string
-	RedisAddr    string
-	RedisPassword string
-	RedisDB      int
-	JWTSecret    string
-	JWTExpiry    time.Duration
+	HTTPPort    string
+	GRPCPort    string
+	DatabaseURL string
+	// DatabaseReplicaURLs are read replicas of DatabaseURL, reads that tolerate lag are routed to them
+	DatabaseReplicaURLs    []string
+	DBReplicaCheckInterval time.Duration
+	Redis                  RedisConfig
+	JWTSecret              string
+	JWTExpiry              time.Duration
+
+	// ProfileCacheTTL is how long profiles and address lists stay cached in Redis, 0 disables the cache
+	ProfileCacheTTL time.Duration
+
+	// IdempotencyTTL is how long the response to a request with an Idempotency-Key is replayed
+	IdempotencyTTL time.Duration
+
+	// MaxAddressesPerUser caps the address book of each user, 0 means no limit
+	MaxAddressesPerUser int
+
+	// What features relying on Redis do while it is unreachable: fail_open or fail_closed
+	SessionsRedisPolicy    string
+	RevocationRedisPolicy  string
+	CacheRedisPolicy       string
+	IdempotencyRedisPolicy string
+
+	// gRPC auxiliary services
+	GRPCHealthEnabled       bool
+	GRPCHealthCheckInterval time.Duration
+	GRPCReflectionEnabled   bool
+	GRPCChannelzPort        string // empty disables the channelz admin endpoint
+	HealthCheckTimeout      time.Duration
+
+	// Tracing, the OTLP exporter itself is configured by the standard OTEL_EXPORTER_OTLP_* variables
+	TracesExporter string // none, otlp, stdout or file
+	TracesFilePath string
+
+	// Logging
+	LogLevel             string // default level: debug, info, warn or error
+	LogComponentLevels   string // per-component overrides, e.g. "gorm=debug,http=warn"
+	LogFormat            string // json or text
+	DBSlowQueryThreshold time.Duration
+
+	// DBAutoMigrate applies pending schema migrations on startup instead of refusing to start
+	DBAutoMigrate bool
+
+	// Outbox relay
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+	OutboxMaxBackoff   time.Duration
+	OutboxRetention    time.Duration
+
+	// Event publishing
+	EventPublisher    string // log, kafka, kafka-rest, nats, redis or memory
+	EventEncoding     string // json or protobuf
+	KafkaBrokers      []string
+	KafkaRESTURL      string // Kafka REST Proxy v2 base URL, for the kafka-rest publisher
+	KafkaTopic        string
+	NATSURL           string
+	NATSSubjectPrefix string
+	NATSJetStream     bool
+	RedisStreamKey    string
+	RedisStreamMaxLen int64 // approximate stream length cap, 0 disables trimming
+
+	// Webhooks
+	WebhookPollInterval time.Duration
+	WebhookConcurrency  int
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+	WebhookMaxBackoff   time.Duration
+
+	// GeoIPDatabasePath is a MaxMind DB file used to resolve login locations, disabled when empty
+	GeoIPDatabasePath string
+
+	// AdminAPIKey protects the /api/admin endpoints, they are disabled when empty
+	AdminAPIKey string
+
+	// ShutdownDrainDelay is how long the service reports not-ready before servers stop accepting requests
+	ShutdownDrainDelay time.Duration
+}
+
+// RedisConfig selects the Redis deployment and how to authenticate to it
+type RedisConfig struct {
+	Mode             string   // standalone, sentinel or cluster
+	Addrs            []string // server address, sentinel addresses or cluster seed nodes
+	MasterName       string   // name of the master monitored by the sentinels
+	Username         string   // ACL user, empty for the default user
+	Password         string
+	SentinelUsername string // ACL user of the sentinels, if they require authentication
+	SentinelPassword string
+	DB               int // must be 0 in cluster mode
+
+	BreakerThreshold  int           // consecutive connection failures that open the circuit breaker
+	ReconnectInterval time.Duration // how often Redis is probed while the circuit is open
+
+	TLSEnabled            bool
+	TLSCAFile             string // PEM bundle verifying the server, system roots when empty
+	TLSCertFile           string // client certificate for mutual TLS
+	TLSKeyFile            string
+	TLSServerName         string // overrides the host name verified against the certificate
+	TLSInsecureSkipVerify bool
 }
 
+// Policies of features that depend on Redis, applied while Redis is unreachable
+const (
+	PolicyFailOpen   = "fail_open"   // carry on without the feature
+	PolicyFailClosed = "fail_closed" // reject the request as temporarily unavailable
+)
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
 	// Set default values
 	cfg := &Config{
-		HTTPPort:     "8080",
-		GRPCPort:     "50051",
-		RedisPassword: "",
-		RedisDB:      0,
-		JWTExpiry:    15 * time.Minute,
+		HTTPPort:  "8080",
+		GRPCPort:  "50051",
+		Redis:     RedisConfig{Mode: "standalone", BreakerThreshold: 5, ReconnectInterval: 5 * time.Second},
+		JWTExpiry: 15 * time.Minute,
+
+		ProfileCacheTTL:        5 * time.Minute,
+		SessionsRedisPolicy:    PolicyFailOpen,
+		RevocationRedisPolicy:  PolicyFailOpen,
+		CacheRedisPolicy:       PolicyFailOpen,
+		IdempotencyTTL:         24 * time.Hour,
+		IdempotencyRedisPolicy: PolicyFailOpen,
+		MaxAddressesPerUser:    20,
+
+		GRPCHealthEnabled:       true,
+		GRPCHealthCheckInterval: 10 * time.Second,
+		GRPCReflectionEnabled:   false,
+		HealthCheckTimeout:      2 * time.Second,
+		ShutdownDrainDelay:      5 * time.Second,
+		TracesExporter:          "none",
+		TracesFilePath:          "traces.json",
+		LogLevel:                "info",
+		LogFormat:               "json",
+		DBSlowQueryThreshold:    200 * time.Millisecond,
+		DBReplicaCheckInterval:  5 * time.Second,
+		OutboxPollInterval:      time.Second,
+		OutboxBatchSize:         100,
+		OutboxMaxBackoff:        5 * time.Minute,
+		OutboxRetention:         7 * 24 * time.Hour,
+		EventPublisher:          "log",
+		EventEncoding:           "json",
+		KafkaTopic:              "user-events",
+		NATSURL:                 "nats://localhost:4222",
+		NATSSubjectPrefix:       "events",
+		NATSJetStream:           true,
+		RedisStreamKey:          "user-events",
+		RedisStreamMaxLen:       100000,
+		WebhookPollInterval:     time.Second,
+		WebhookConcurrency:      4,
+		WebhookTimeout:          10 * time.Second,
+		WebhookMaxAttempts:      10,
+		WebhookMaxBackoff:       time.Hour,
 	}
 
 	// Load from environment variables
 	cfg.HTTPPort = getEnv("HTTP_PORT", cfg.HTTPPort)
 	cfg.GRPCPort = getEnv("GRPC_PORT", cfg.GRPCPort)
 	cfg.DatabaseURL = getEnvOrPanic("DATABASE_URL")
-	cfg.RedisAddr = getEnvOrPanic("REDIS_ADDR")
-	cfg.RedisPassword = getEnv("REDIS_PASSWORD", cfg.RedisPassword)
-	redisDBStr := getEnv("REDIS_DB", strconv.Itoa(cfg.RedisDB))
-	redisDB, err := strconv.Atoi(redisDBStr)
-	if err != nil {
-		log.Printf("Invalid REDIS_DB value: %s. Using default: %d\n", redisDBStr, cfg.RedisDB)
-	} else {
-		cfg.RedisDB = redisDB
-	}
+	cfg.Redis.Mode = getEnv("REDIS_MODE", cfg.Redis.Mode)
+	cfg.Redis.Addrs = splitList(getEnvOrPanic("REDIS_ADDR"))
+	cfg.Redis.MasterName = getEnv("REDIS_MASTER_NAME", cfg.Redis.MasterName)
+	cfg.Redis.Username = getEnv("REDIS_USERNAME", cfg.Redis.Username)
+	cfg.Redis.Password = getEnv("REDIS_PASSWORD", cfg.Redis.Password)
+	cfg.Redis.SentinelUsername = getEnv("REDIS_SENTINEL_USERNAME", cfg.Redis.SentinelUsername)
+	cfg.Redis.SentinelPassword = getEnv("REDIS_SENTINEL_PASSWORD", cfg.Redis.SentinelPassword)
+	cfg.Redis.DB = getEnvInt("REDIS_DB", cfg.Redis.DB)
+	cfg.Redis.TLSEnabled = getEnvBool("REDIS_TLS_ENABLED", cfg.Redis.TLSEnabled)
+	cfg.Redis.TLSCAFile = getEnv("REDIS_TLS_CA_FILE", cfg.Redis.TLSCAFile)
+	cfg.Redis.TLSCertFile = getEnv("REDIS_TLS_CERT_FILE", cfg.Redis.TLSCertFile)
+	cfg.Redis.TLSKeyFile = getEnv("REDIS_TLS_KEY_FILE", cfg.Redis.TLSKeyFile)
+	cfg.Redis.TLSServerName = getEnv("REDIS_TLS_SERVER_NAME", cfg.Redis.TLSServerName)
+	cfg.Redis.TLSInsecureSkipVerify = getEnvBool("REDIS_TLS_INSECURE_SKIP_VERIFY", cfg.Redis.TLSInsecureSkipVerify)
+	cfg.Redis.BreakerThreshold = getEnvInt("REDIS_BREAKER_FAILURES", cfg.Redis.BreakerThreshold)
+	cfg.Redis.ReconnectInterval = getEnvDuration("REDIS_RECONNECT_INTERVAL_SECONDS", cfg.Redis.ReconnectInterval, time.Second)
+	cfg.SessionsRedisPolicy = getEnvOneOf("REDIS_POLICY_SESSIONS", cfg.SessionsRedisPolicy, PolicyFailOpen, PolicyFailClosed)
+	cfg.RevocationRedisPolicy = getEnvOneOf("REDIS_POLICY_REVOCATION", cfg.RevocationRedisPolicy, PolicyFailOpen, PolicyFailClosed)
+	cfg.CacheRedisPolicy = getEnvOneOf("REDIS_POLICY_CACHE", cfg.CacheRedisPolicy, PolicyFailOpen, PolicyFailClosed)
+	cfg.IdempotencyRedisPolicy = getEnvOneOf("REDIS_POLICY_IDEMPOTENCY", cfg.IdempotencyRedisPolicy, PolicyFailOpen, PolicyFailClosed)
 
 	cfg.JWTSecret = getEnvOrPanic("JWT_SECRET")
 	jwtExpiryStr := getEnv("JWT_EXPIRY_MINUTES", "15")
 	jwtExpiryMinutes, err := strconv.Atoi(jwtExpiryStr)
 	if err != nil {
-		log.Printf("Invalid JWT_EXPIRY_MINUTES value: %s. Using default: 15 minutes\n", jwtExpiryStr)
+		slog.Warn("Invalid JWT_EXPIRY_MINUTES value, using default", "value", jwtExpiryStr, "default", 15)
 	} else {
 		cfg.JWTExpiry = time.Duration(jwtExpiryMinutes) * time.Minute
 	}
 
+	cfg.ProfileCacheTTL = getEnvDuration("PROFILE_CACHE_TTL_SECONDS", cfg.ProfileCacheTTL, time.Second)
+	cfg.IdempotencyTTL = getEnvDuration("IDEMPOTENCY_TTL_HOURS", cfg.IdempotencyTTL, time.Hour)
+	cfg.MaxAddressesPerUser = getEnvInt("MAX_ADDRESSES_PER_USER", cfg.MaxAddressesPerUser)
+
+	cfg.GRPCHealthEnabled = getEnvBool("GRPC_HEALTH_ENABLED", cfg.GRPCHealthEnabled)
+	cfg.GRPCHealthCheckInterval = getEnvDuration("GRPC_HEALTH_CHECK_INTERVAL_SECONDS", cfg.GRPCHealthCheckInterval, time.Second)
+	cfg.GRPCReflectionEnabled = getEnvBool("GRPC_REFLECTION_ENABLED", cfg.GRPCReflectionEnabled)
+	cfg.GRPCChannelzPort = getEnv("GRPC_CHANNELZ_PORT", cfg.GRPCChannelzPort)
+	cfg.HealthCheckTimeout = getEnvDuration("HEALTH_CHECK_TIMEOUT_MS", cfg.HealthCheckTimeout, time.Millisecond)
+	cfg.ShutdownDrainDelay = getEnvDuration("SHUTDOWN_DRAIN_SECONDS", cfg.ShutdownDrainDelay, time.Second)
+	cfg.TracesExporter = getEnv("OTEL_TRACES_EXPORTER", cfg.TracesExporter)
+	cfg.TracesFilePath = getEnv("OTEL_TRACES_FILE_PATH", cfg.TracesFilePath)
+	cfg.LogLevel = getEnv("LOG_LEVEL", cfg.LogLevel)
+	cfg.LogComponentLevels = getEnv("LOG_LEVELS", cfg.LogComponentLevels)
+	cfg.LogFormat = getEnv("LOG_FORMAT", cfg.LogFormat)
+	cfg.DBSlowQueryThreshold = getEnvDuration("DB_SLOW_QUERY_MS", cfg.DBSlowQueryThreshold, time.Millisecond)
+	cfg.DBAutoMigrate = getEnvBool("DB_AUTO_MIGRATE", cfg.DBAutoMigrate)
+	cfg.DatabaseReplicaURLs = getEnvList("DATABASE_REPLICA_URLS")
+	cfg.DBReplicaCheckInterval = getEnvDuration("DB_REPLICA_CHECK_INTERVAL_SECONDS", cfg.DBReplicaCheckInterval, time.Second)
+	cfg.OutboxPollInterval = getEnvDuration("OUTBOX_POLL_INTERVAL_MS", cfg.OutboxPollInterval, time.Millisecond)
+	cfg.OutboxBatchSize = getEnvInt("OUTBOX_BATCH_SIZE", cfg.OutboxBatchSize)
+	cfg.OutboxMaxBackoff = getEnvDuration("OUTBOX_MAX_BACKOFF_SECONDS", cfg.OutboxMaxBackoff, time.Second)
+	cfg.OutboxRetention = getEnvDuration("OUTBOX_RETENTION_HOURS", cfg.OutboxRetention, time.Hour)
+	cfg.EventPublisher = getEnv("EVENT_PUBLISHER", cfg.EventPublisher)
+	cfg.EventEncoding = getEnv("EVENT_ENCODING", cfg.EventEncoding)
+	cfg.KafkaBrokers = getEnvList("KAFKA_BROKERS")
+	cfg.KafkaRESTURL = getEnv("KAFKA_REST_URL", cfg.KafkaRESTURL)
+	cfg.KafkaTopic = getEnv("KAFKA_TOPIC", cfg.KafkaTopic)
+	cfg.NATSURL = getEnv("NATS_URL", cfg.NATSURL)
+	cfg.NATSSubjectPrefix = getEnv("NATS_SUBJECT_PREFIX", cfg.NATSSubjectPrefix)
+	cfg.NATSJetStream = getEnvBool("NATS_JETSTREAM", cfg.NATSJetStream)
+	cfg.RedisStreamKey = getEnv("REDIS_STREAM_KEY", cfg.RedisStreamKey)
+	cfg.RedisStreamMaxLen = int64(getEnvInt("REDIS_STREAM_MAXLEN", int(cfg.RedisStreamMaxLen)))
+	cfg.WebhookPollInterval = getEnvDuration("WEBHOOK_POLL_INTERVAL_MS", cfg.WebhookPollInterval, time.Millisecond)
+	cfg.WebhookConcurrency = getEnvInt("WEBHOOK_CONCURRENCY", cfg.WebhookConcurrency)
+	cfg.WebhookTimeout = getEnvDuration("WEBHOOK_TIMEOUT_SECONDS", cfg.WebhookTimeout, time.Second)
+	cfg.WebhookMaxAttempts = getEnvInt("WEBHOOK_MAX_ATTEMPTS", cfg.WebhookMaxAttempts)
+	cfg.WebhookMaxBackoff = getEnvDuration("WEBHOOK_MAX_BACKOFF_SECONDS", cfg.WebhookMaxBackoff, time.Second)
+	cfg.AdminAPIKey = getEnv("ADMIN_API_KEY", cfg.AdminAPIKey)
+	cfg.GeoIPDatabasePath = getEnv("GEOIP_DB_PATH", cfg.GeoIPDatabasePath)
+
 	return cfg
 }
 
@@ -64,11 +251,76 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvInt retrieves an integer environment variable, returns fallback value if not found or invalid
+func getEnvInt(key string, fallback int) int {
+	valueStr, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		slog.Warn("Invalid integer environment variable, using default", "key", key, "value", valueStr, "default", fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvDuration retrieves an integer environment variable expressed in unit, returns fallback value if not found or invalid
+func getEnvDuration(key string, fallback, unit time.Duration) time.Duration {
+	return time.Duration(getEnvInt(key, int(fallback/unit))) * unit
+}
+
+// getEnvBool retrieves a boolean environment variable, returns fallback value if not found or invalid
+func getEnvBool(key string, fallback bool) bool {
+	valueStr, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		slog.Warn("Invalid boolean environment variable, using default", "key", key, "value", valueStr, "default", fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvOneOf retrieves an environment variable that must be one of allowed, returns fallback value if not found or invalid
+func getEnvOneOf(key, fallback string, allowed ...string) string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	for _, candidate := range allowed {
+		if value == candidate {
+			return value
+		}
+	}
+	slog.Warn("Invalid environment variable, using default", "key", key, "value", value, "allowed", allowed, "default", fallback)
+	return fallback
+}
+
+// getEnvList splits a comma-separated variable, empty entries are dropped
+func getEnvList(key string) []string {
+	return splitList(os.Getenv(key))
+}
+
+// splitList splits a comma-separated list and trims its entries, empty entries are dropped
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // getEnvOrPanic retrieves environment variable, panics if not found
 func getEnvOrPanic(key string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
 	}
-	log.Fatalf("Environment variable %s is not set", key)
+	slog.Error("Environment variable is not set", "key", key)
+	os.Exit(1)
 	return "" // This line will not be executed
-} 
\ No newline at end of file
+}
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"gorm.io/gorm"
+)
+
+// Audited actions
+const (
+	ActionLogin         = "auth.login"
+	ActionRegister      = "user.register"
+	ActionProfileUpdate = "user.profile_update"
+	ActionAddressAdd    = "address.add"
+	ActionAddressUpdate = "address.update"
+	ActionAddressDelete = "address.delete"
+	ActionAddressMerge  = "address.merge"
+)
+
+// Actor types
+const (
+	ActorTypeUser      = "user"      // an authenticated end user, ActorID is set
+	ActorTypeSystem    = "system"    // a trusted internal service, e.g. a gRPC client
+	ActorTypeAnonymous = "anonymous" // an unauthenticated request such as signup or login
+)
+
+// Outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// MaxUserAgentLength is the number of user agent bytes stored with audit and login history entries
+const MaxUserAgentLength = 512
+
+// Change is the old and new value of a single field
+type Change struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// Entry is an action to be recorded
+type Entry struct {
+	Action       string
+	Outcome      string
+	TargetUserID uint // 0 when the affected user is unknown
+	Changes      map[string]Change
+	Details      map[string]interface{}
+}
+
+// NewLog builds the audit log row for entry.
+// Actor, client address and request ID are taken from ctx.
+func NewLog(ctx context.Context, entry Entry) (*model.AuditLog, error) {
+	actor := ActorFromContext(ctx)
+	row := model.AuditLog{
+		OccurredAt: time.Now().UTC(),
+		Action:     entry.Action,
+		Outcome:    entry.Outcome,
+		ActorType:  actor.Type(),
+		IP:         actor.IP,
+		UserAgent:  Truncate(actor.UserAgent, MaxUserAgentLength),
+		RequestID:  logger.RequestIDFromContext(ctx),
+	}
+	if row.Outcome == "" {
+		row.Outcome = OutcomeSuccess
+	}
+	if actor.UserID != 0 {
+		row.ActorID = &actor.UserID
+	}
+	if entry.TargetUserID != 0 {
+		row.TargetUserID = &entry.TargetUserID
+	}
+	if len(entry.Changes) > 0 {
+		changes, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode audit changes: %w", err)
+		}
+		row.Changes = string(changes)
+	}
+	if len(entry.Details) > 0 {
+		details, err := json.Marshal(entry.Details)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode audit details: %w", err)
+		}
+		row.Details = string(details)
+	}
+	return &row, nil
+}
+
+// Record appends entry to the audit log using db.
+// Mutations pass their transaction so that the entry is stored if and only if the change is committed.
+func Record(ctx context.Context, db *gorm.DB, entry Entry) error {
+	row, err := NewLog(ctx, entry)
+	if err != nil {
+		return err
+	}
+	if err := db.WithContext(ctx).Create(row).Error; err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Diff returns the fields whose value differs between the two snapshots.
+// A nil before (creation) or after (deletion) reports every field of the other snapshot.
+func Diff(before, after map[string]interface{}) map[string]Change {
+	changes := make(map[string]Change)
+	for field, oldValue := range before {
+		newValue, ok := after[field]
+		if !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes[field] = Change{Old: oldValue, New: newValue}
+		}
+	}
+	for field, newValue := range after {
+		if _, ok := before[field]; !ok {
+			changes[field] = Change{New: newValue}
+		}
+	}
+	return changes
+}
+
+// Truncate cuts s to at most n bytes without splitting a UTF-8 character, for values stored in sized columns
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewLogActor(t *testing.T) {
+	anonymous := WithClient(context.Background(), "203.0.113.7", "curl/8.0")
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantType  string
+		wantActor uint
+	}{
+		{"anonymous request", anonymous, ActorTypeAnonymous, 0},
+		{"authenticated user", WithUserID(anonymous, 7), ActorTypeUser, 7},
+		{"internal system", WithSystem(anonymous), ActorTypeSystem, 0},
+		{"user wins over system", WithUserID(WithSystem(anonymous), 7), ActorTypeUser, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row, err := NewLog(tt.ctx, Entry{Action: ActionRegister, TargetUserID: 7})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if row.ActorType != tt.wantType {
+				t.Errorf("ActorType = %q, want %q", row.ActorType, tt.wantType)
+			}
+			var actorID uint
+			if row.ActorID != nil {
+				actorID = *row.ActorID
+			}
+			if actorID != tt.wantActor {
+				t.Errorf("ActorID = %d, want %d", actorID, tt.wantActor)
+			}
+			if row.IP != "203.0.113.7" || row.UserAgent != "curl/8.0" || row.Outcome != OutcomeSuccess {
+				t.Errorf("row = %+v, want client info and default outcome", row)
+			}
+		})
+	}
+}
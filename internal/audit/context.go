@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"context"
+)
+
+// Actor describes who performed an action and from where
+type Actor struct {
+	UserID    uint // 0 for anonymous requests
+	System    bool // set for trusted internal callers that act on behalf of the platform
+	IP        string
+	UserAgent string
+}
+
+// Type classifies the actor as ActorTypeUser, ActorTypeSystem or ActorTypeAnonymous
+func (a Actor) Type() string {
+	switch {
+	case a.UserID != 0:
+		return ActorTypeUser
+	case a.System:
+		return ActorTypeSystem
+	default:
+		return ActorTypeAnonymous
+	}
+}
+
+type actorKey struct{}
+
+// WithClient returns a copy of ctx carrying the client address and user agent of the request
+func WithClient(ctx context.Context, ip, userAgent string) context.Context {
+	actor := ActorFromContext(ctx)
+	actor.IP = ip
+	actor.UserAgent = userAgent
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithUserID returns a copy of ctx carrying the ID of the authenticated user
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	actor := ActorFromContext(ctx)
+	actor.UserID = userID
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithSystem returns a copy of ctx marking the caller as a trusted internal system, such as a gRPC client
+func WithSystem(ctx context.Context) context.Context {
+	actor := ActorFromContext(ctx)
+	actor.System = true
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, or an anonymous actor
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
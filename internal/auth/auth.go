@@ -9,6 +9,16 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Token validation errors returned by ValidateJWT
+var (
+	ErrTokenExpired     = errors.New("token has expired")
+	ErrTokenMalformed   = errors.New("malformed token")
+	ErrTokenNotValidYet = errors.New("token is not yet valid")
+	ErrTokenInvalid     = errors.New("invalid token")
+	// ErrTokenRevoked is returned by the service for a validly signed token issued before its user's tokens were revoked
+	ErrTokenRevoked = errors.New("token has been revoked")
+)
+
 // Claims custom JWT Claims
 type Claims struct {
 	UserID uint   `json:"user_id"`
@@ -61,9 +71,9 @@ func ValidateJWT(tokenString string, secret string) (*Claims, error) {
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
-			return nil, errors.New("token has expired")
+			return nil, ErrTokenExpired
 		} else if errors.Is(err, jwt.ErrTokenMalformed) {
-            return nil, errors.New("malformed token")
+            return nil, ErrTokenMalformed
         } else if !errors.Is(err, jwt.ErrTokenNotValidYet) && err != nil {
             return nil, fmt.Errorf("couldn't handle this token: %w", err)
         }
@@ -75,8 +85,8 @@ func ValidateJWT(tokenString string, secret string) (*Claims, error) {
         // Allow not-yet-valid tokens if business logic requires
         // log.Printf("Token is not yet valid, but allowing. UserID: %d", claims.UserID)
         // return claims, nil
-        return nil, errors.New("token is not yet valid")
+        return nil, ErrTokenNotValidYet
     }
 
-	return nil, errors.New("invalid token")
+	return nil, ErrTokenInvalid
 } 
\ No newline at end of file
@@ -0,0 +1,170 @@
+// Package country holds the address rules of every ISO 3166-1 country: postal code formats,
+// the subdivisions (states, provinces, prefectures) an address must name and how addresses are
+// laid out. The rules are embedded in the binary so that addresses can be validated and
+// formatted without network access.
+package country
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//go:embed countries.json
+var countriesJSON []byte
+
+// Subdivision styles decide how the state of an address is stored
+const (
+	StyleCode = "code" // the postal abbreviation, e.g. "CA" for California
+	StyleName = "name" // the English name, e.g. "Tokyo"
+)
+
+// Country holds the address rules of one country
+type Country struct {
+	Code    string   `json:"code"` // ISO 3166-1 alpha-2
+	Name    string   `json:"name"` // English short name
+	Aliases []string `json:"aliases,omitempty"`
+
+	// PostalPattern matches the whole postal code after upper-casing; empty when the country has no postal codes we know the format of
+	PostalPattern string `json:"postal_pattern,omitempty"`
+	// PostalFormat rewrites a matching postal code to its canonical form, e.g. "$1 $2"; empty keeps the code as entered
+	PostalFormat   string `json:"postal_format,omitempty"`
+	PostalExample  string `json:"postal_example,omitempty"`
+	PostalOptional bool   `json:"postal_optional,omitempty"`
+
+	// Layout lists the lines of a formatted address, e.g. "{street}\n{city}, {state} {postal_code}"; empty uses a default
+	Layout string `json:"layout,omitempty"`
+
+	// SubdivisionLabel names the subdivision in messages, e.g. "state" or "prefecture"
+	SubdivisionLabel string        `json:"subdivision_label,omitempty"`
+	SubdivisionStyle string        `json:"subdivision_style,omitempty"`
+	Subdivisions     []Subdivision `json:"subdivisions,omitempty"`
+
+	postal        *regexp.Regexp
+	lines         [][]layoutPart
+	subdivisionBy map[string]*Subdivision
+}
+
+// Subdivision is a first-level subdivision of a country
+type Subdivision struct {
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// byKey finds countries by code, name and aliases folded by key
+var byKey map[string]*Country
+
+func init() {
+	var err error
+	byKey, err = load(countriesJSON)
+	if err != nil {
+		panic(fmt.Sprintf("country: invalid embedded data: %v", err))
+	}
+}
+
+func load(data []byte) (map[string]*Country, error) {
+	var list []*Country
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	index := make(map[string]*Country)
+	add := func(name string, c *Country) error {
+		k := key(name)
+		if other, ok := index[k]; ok && other != c {
+			return fmt.Errorf("%q names both %s and %s", name, other.Code, c.Code)
+		}
+		index[k] = c
+		return nil
+	}
+	for _, c := range list {
+		if c.PostalPattern != "" {
+			re, err := regexp.Compile("^(?:" + c.PostalPattern + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("postal pattern of %s: %w", c.Code, err)
+			}
+			c.postal = re
+		}
+		if c.Layout != "" {
+			lines, err := parseLayout(c.Layout)
+			if err != nil {
+				return nil, fmt.Errorf("layout of %s: %w", c.Code, err)
+			}
+			c.lines = lines
+		}
+		c.subdivisionBy = make(map[string]*Subdivision)
+		for i := range c.Subdivisions {
+			sub := &c.Subdivisions[i]
+			for _, name := range append([]string{sub.Code, sub.Name}, sub.Aliases...) {
+				if other, ok := c.subdivisionBy[key(name)]; ok && other != sub {
+					return nil, fmt.Errorf("%q names both %s-%s and %s-%s", name, c.Code, other.Code, c.Code, sub.Code)
+				}
+				c.subdivisionBy[key(name)] = sub
+			}
+		}
+		for _, name := range append([]string{c.Code, c.Name}, c.Aliases...) {
+			if err := add(name, c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return index, nil
+}
+
+// key folds a name for lookups: case, dots and repeated whitespace are ignored, so "B.C." finds "BC"
+func key(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(name, ".", "")), " "))
+}
+
+// Lookup finds a country by its alpha-2 code, English name or one of its aliases, ignoring case
+func Lookup(name string) (*Country, bool) {
+	c, ok := byKey[key(name)]
+	return c, ok
+}
+
+// PostalRequired reports whether addresses in the country must have a postal code
+func (c *Country) PostalRequired() bool {
+	return c.postal != nil && !c.PostalOptional
+}
+
+// NormalizePostalCode upper-cases the postal code and rewrites it to the canonical format of the country.
+// ok is false if the code does not match the format. Countries without a known format accept any code.
+func (c *Country) NormalizePostalCode(code string) (normalized string, ok bool) {
+	normalized = strings.ToUpper(strings.Join(strings.Fields(code), " "))
+	if c.postal == nil {
+		return normalized, true
+	}
+	// Separators are optional in most formats, so also try the code without spaces
+	match := c.postal.FindStringSubmatchIndex(normalized)
+	if match == nil {
+		normalized = strings.ReplaceAll(normalized, " ", "")
+		if match = c.postal.FindStringSubmatchIndex(normalized); match == nil {
+			return "", false
+		}
+	}
+	if c.PostalFormat == "" {
+		return normalized, true
+	}
+	return string(c.postal.ExpandString(nil, c.PostalFormat, normalized, match)), true
+}
+
+// HasSubdivisions reports whether addresses in the country must name one of its subdivisions
+func (c *Country) HasSubdivisions() bool {
+	return len(c.Subdivisions) > 0
+}
+
+// Subdivision finds a subdivision by code, name or alias, ignoring case
+func (c *Country) Subdivision(name string) (*Subdivision, bool) {
+	sub, ok := c.subdivisionBy[key(name)]
+	return sub, ok
+}
+
+// SubdivisionValue returns how the subdivision is stored in addresses of the country
+func (c *Country) SubdivisionValue(sub *Subdivision) string {
+	if c.SubdivisionStyle == StyleCode {
+		return sub.Code
+	}
+	return sub.Name
+}
@@ -0,0 +1,132 @@
+package country
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+// defaultLayout is used for countries without a layout of their own
+const defaultLayout = "{street}\n{city}\n{state} {postal_code}"
+
+// layoutFields are the address fields a layout can refer to
+var layoutFields = map[string]func(addr *model.Address) string{
+	"street":      func(addr *model.Address) string { return addr.Street },
+	"city":        func(addr *model.Address) string { return addr.City },
+	"state":       func(addr *model.Address) string { return addr.State },
+	"postal_code": func(addr *model.Address) string { return addr.PostalCode },
+}
+
+// layoutPart is a field of the address or literal text between fields
+type layoutPart struct {
+	field     string
+	text      string
+	separator bool // text without letters or digits, only written between two non-empty values
+}
+
+// parseLayout splits a layout into lines of parts, e.g. "{city}, {state}" into city, ", " and state
+func parseLayout(layout string) ([][]layoutPart, error) {
+	var lines [][]layoutPart
+	for _, line := range strings.Split(layout, "\n") {
+		var parts []layoutPart
+		for line != "" {
+			start := strings.IndexByte(line, '{')
+			if start != 0 {
+				if start < 0 {
+					start = len(line)
+				}
+				parts = append(parts, literal(line[:start]))
+				line = line[start:]
+				continue
+			}
+			end := strings.IndexByte(line, '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated field in %q", line)
+			}
+			field := line[1:end]
+			if layoutFields[field] == nil {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+			parts = append(parts, layoutPart{field: field})
+			line = line[end+1:]
+		}
+		lines = append(lines, parts)
+	}
+	return lines, nil
+}
+
+func literal(text string) layoutPart {
+	separator := strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0
+	return layoutPart{text: text, separator: separator}
+}
+
+var defaultLines = mustParseLayout(defaultLayout)
+
+func mustParseLayout(layout string) [][]layoutPart {
+	lines, err := parseLayout(layout)
+	if err != nil {
+		panic(err)
+	}
+	return lines
+}
+
+// Lines lays addr out as is customary in its country, one entry per line, and ends with the country name.
+// Empty fields are left out together with the separators around them, empty lines are dropped.
+func Lines(addr *model.Address) []string {
+	layout, countryLine := defaultLines, addr.Country
+	if c, ok := Lookup(addr.Country); ok {
+		countryLine = c.Name
+		if c.lines != nil {
+			layout = c.lines
+		}
+	}
+
+	var lines []string
+	for _, parts := range layout {
+		if line := formatLine(parts, addr); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if countryLine != "" {
+		lines = append(lines, countryLine)
+	}
+	return lines
+}
+
+// formatLine writes the values of a layout line. Of several separators around empty fields only the first is kept.
+func formatLine(parts []layoutPart, addr *model.Address) string {
+	var b strings.Builder
+	pending := ""
+	for _, part := range parts {
+		value := part.text
+		if part.field != "" {
+			value = layoutFields[part.field](addr)
+		} else if part.separator {
+			if pending == "" {
+				pending = value
+			}
+			continue
+		}
+		if value == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(pending)
+		}
+		pending = ""
+		b.WriteString(value)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// MultiLine formats addr for printing on labels and envelopes, lines are separated by "\n"
+func MultiLine(addr *model.Address) string {
+	return strings.Join(Lines(addr), "\n")
+}
+
+// SingleLine formats addr on one line, e.g. for emails and lists
+func SingleLine(addr *model.Address) string {
+	return strings.Join(Lines(addr), ", ")
+}
@@ -0,0 +1,82 @@
+package country
+
+import (
+	"testing"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		addr       model.Address
+		multiLine  string
+		singleLine string
+	}{
+		{"country layout",
+			model.Address{Street: "1 Infinite Loop", City: "Cupertino", State: "CA", PostalCode: "95014", Country: "US"},
+			"1 Infinite Loop\nCupertino, CA 95014\nUnited States",
+			"1 Infinite Loop, Cupertino, CA 95014, United States"},
+		{"separator before an empty first field is dropped",
+			model.Address{Street: "1 Infinite Loop", State: "CA", PostalCode: "95014", Country: "US"},
+			"1 Infinite Loop\nCA 95014\nUnited States",
+			"1 Infinite Loop, CA 95014, United States"},
+		{"separators around empty fields collapse to one",
+			model.Address{Street: "1 Infinite Loop", City: "Cupertino", PostalCode: "95014", Country: "US"},
+			"1 Infinite Loop\nCupertino, 95014\nUnited States",
+			"1 Infinite Loop, Cupertino, 95014, United States"},
+		{"trailing separators are dropped",
+			model.Address{Street: "1 Infinite Loop", City: "Cupertino", Country: "US"},
+			"1 Infinite Loop\nCupertino\nUnited States",
+			"1 Infinite Loop, Cupertino, United States"},
+		{"literal text is kept",
+			model.Address{Street: "Av. Paulista, 1578", City: "São Paulo", State: "SP", PostalCode: "01310-200", Country: "BR"},
+			"Av. Paulista, 1578\nSão Paulo-SP\n01310-200\nBrazil",
+			"Av. Paulista, 1578, São Paulo-SP, 01310-200, Brazil"},
+		{"default layout",
+			model.Address{Street: "Norzin Lam", City: "Thimphu", State: "Thimphu", PostalCode: "11001", Country: "BT"},
+			"Norzin Lam\nThimphu\nThimphu 11001\nBhutan",
+			"Norzin Lam, Thimphu, Thimphu 11001, Bhutan"},
+		{"empty lines are dropped",
+			model.Address{Street: "Norzin Lam", City: "Thimphu", Country: "BT"},
+			"Norzin Lam\nThimphu\nBhutan",
+			"Norzin Lam, Thimphu, Bhutan"},
+		{"unknown country uses the default layout and keeps the country as entered",
+			model.Address{Street: "1 Coral Way", City: "Poseidonis", PostalCode: "12345", Country: "Atlantis"},
+			"1 Coral Way\nPoseidonis\n12345\nAtlantis",
+			"1 Coral Way, Poseidonis, 12345, Atlantis"},
+		{"no country",
+			model.Address{Street: "1 Coral Way", City: "Poseidonis"},
+			"1 Coral Way\nPoseidonis",
+			"1 Coral Way, Poseidonis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MultiLine(&tt.addr); got != tt.multiLine {
+				t.Errorf("MultiLine() = %q, want %q", got, tt.multiLine)
+			}
+			if got := SingleLine(&tt.addr); got != tt.singleLine {
+				t.Errorf("SingleLine() = %q, want %q", got, tt.singleLine)
+			}
+		})
+	}
+}
+
+func TestParseLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  string
+		wantErr bool
+	}{
+		{"fields and literals", "{street}\n{postal_code} {city} CEDEX", false},
+		{"unterminated field", "{street}\n{city", true},
+		{"unknown field", "{street}\n{county}", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseLayout(tt.layout); (err != nil) != tt.wantErr {
+				t.Errorf("parseLayout(%q) error = %v, want error %v", tt.layout, err, tt.wantErr)
+			}
+		})
+	}
+}
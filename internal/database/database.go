@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var DB *gorm.DB
+
+// Open initializes the database connection without touching the schema.
+// The driver is selected from the scheme of databaseURL, see parseDatabaseURL.
+func Open(databaseURL string, slowQueryThreshold time.Duration) (*gorm.DB, error) {
+	dialect, err := parseDatabaseURL(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	DB, err = openGorm(dialect.dialector, dialect.system, slowQueryThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	// Connection pool settings
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get generic database object: %w", err)
+	}
+	configurePool(sqlDB, dialect)
+
+	// Expose connection pool statistics on /metrics
+	if err := metrics.RegisterDBStats(sqlDB, dialect.name); err != nil {
+		return nil, err
+	}
+
+	log.Info("Database connection established.", "dialect", dialect.name)
+
+	return DB, nil
+}
+
+// openGorm opens a GORM DB with the settings shared by primary and replica connections
+func openGorm(dialector gorm.Dialector, system attribute.KeyValue, slowQueryThreshold time.Duration) (*gorm.DB, error) {
+	// 配置 GORM，禁用自动创建外键和索引
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: newGormLogger(slowQueryThreshold), // Log level is controlled by the "gorm" component level
+		DisableForeignKeyConstraintWhenMigrating: true, // 禁用外键约束自动创建
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true, // 使用单数表名
+		},
+		TranslateError: true, // Map driver specific errors such as unique violations to gorm.ErrDuplicatedKey
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect database: %w", err)
+	}
+
+	// Wrap every query in a tracing span
+	if err := db.Use(newTracingPlugin(system)); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
+	return db, nil
+}
+
+// configurePool applies the connection pool settings
+func configurePool(sqlDB *sql.DB, dialect dialect) {
+	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+	if dialect.inMemory {
+		// Every connection would open its own empty in-memory database
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+	}
+}
+
+// InitDatabase connects to the database and verifies that the schema is at the version this build expects.
+// With autoMigrate pending migrations are applied first, otherwise they must be applied with the migrate subcommand.
+func InitDatabase(databaseURL string, slowQueryThreshold time.Duration, autoMigrate bool) (*gorm.DB, error) {
+	db, err := Open(databaseURL, slowQueryThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	if autoMigrate {
+		applied, err := migrator.Up(ctx, 0)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Database migration completed.", "applied", applied, "version", migrator.Latest())
+	}
+	if err := migrator.CheckVersion(ctx); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// GetDB returns the initialized database connection instance
+func GetDB() *gorm.DB {
+	return DB
+} 
\ No newline at end of file
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+var log = logger.For("gorm")
+
+// slogGormLogger adapts GORM's logger to the structured logger.
+// Executed statements are logged at debug level, slow statements at warn and failures at error.
+// The effective level is controlled by the "gorm" component level (LOG_LEVELS=gorm=debug).
+// Bound values can hold secrets and payloads, statements keep their placeholders unless debug logging is enabled.
+type slogGormLogger struct {
+	slowThreshold time.Duration
+}
+
+var (
+	_ gormLogger.Interface = (*slogGormLogger)(nil)
+	_ gorm.ParamsFilter    = (*slogGormLogger)(nil)
+)
+
+// newGormLogger creates a GORM logger reporting statements slower than slowThreshold as warnings
+func newGormLogger(slowThreshold time.Duration) gormLogger.Interface {
+	return &slogGormLogger{slowThreshold: slowThreshold}
+}
+
+// LogMode is a no-op, levels are configured through the component level instead
+func (l *slogGormLogger) LogMode(gormLogger.LogLevel) gormLogger.Interface {
+	return l
+}
+
+func (l *slogGormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	log.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *slogGormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	log.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *slogGormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	log.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// ParamsFilter is called by GORM before rendering bound values into a logged statement,
+// dropping the values leaves the statement with its placeholders as the tracing plugin records it
+func (l *slogGormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if log.Enabled(ctx, slog.LevelDebug) {
+		return sql, params
+	}
+	return sql, nil
+}
+
+func (l *slogGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		log.ErrorContext(ctx, "SQL statement failed", "sql", sql, "rows", rows, "elapsed_ms", elapsed.Milliseconds(), "error", err)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold:
+		sql, rows := fc()
+		log.WarnContext(ctx, "Slow SQL statement", "sql", sql, "rows", rows, "elapsed_ms", elapsed.Milliseconds(), "threshold_ms", l.slowThreshold.Milliseconds())
+	case log.Enabled(ctx, slog.LevelDebug):
+		// fc renders bound values into the statement at this level, only call it when the line will be written
+		sql, rows := fc()
+		log.DebugContext(ctx, "SQL statement executed", "sql", sql, "rows", rows, "elapsed_ms", elapsed.Milliseconds())
+	}
+}
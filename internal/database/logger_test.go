@@ -0,0 +1,41 @@
+package database
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/blackwatch66/user-microservice/internal/logger"
+)
+
+func TestGormLoggerBoundValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		levels     string
+		wantValues bool
+	}{
+		{"failed statement keeps its placeholders", "", false},
+		{"debug level renders the values", "gorm=debug", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := openTestSQLite(t)
+			var out bytes.Buffer
+			if err := logger.Init(&out, "info", tt.levels, "json"); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { logger.Init(os.Stderr, "info", "", "json") })
+
+			if err := db.Exec("INSERT INTO missing_table (payload) VALUES (?)", "customer payload").Error; err == nil {
+				t.Fatal("insert into a missing table succeeded")
+			}
+			if !strings.Contains(out.String(), "SQL statement failed") {
+				t.Fatalf("log = %s, want the failed statement", out.String())
+			}
+			if got := strings.Contains(out.String(), "customer payload"); got != tt.wantValues {
+				t.Errorf("log has the bound value = %v, want %v: %s", got, tt.wantValues, out.String())
+			}
+		})
+	}
+}
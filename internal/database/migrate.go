@@ -0,0 +1,422 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationFiles holds the up/down SQL of every dialect, named <version>_<name>.<up|down>.sql
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// ErrDirtySchema is returned when a previous migration failed halfway and must be repaired by hand
+var ErrDirtySchema = errors.New("database schema is dirty")
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+	Dirty     bool
+}
+
+// schemaMigration records an applied migration.
+// Dirty is set while the migration runs, a row left dirty means the schema is in an unknown state.
+type schemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	Dirty     bool      `gorm:"not null;default:false"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migration"
+}
+
+const (
+	// migrationLockName identifies the lock held while migrations run
+	migrationLockName = "user_service_schema_migration"
+	// migrationLockTimeout bounds the wait for another instance to finish migrating
+	migrationLockTimeout = 5 * time.Minute
+)
+
+// Migrator applies the embedded migrations of the connected dialect
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []Migration // sorted by version
+}
+
+// NewMigrator loads the migrations for the dialect of db
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := loadMigrations(migrationFiles, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// loadMigrations parses the files of one dialect below migrations/ in fsys
+func loadMigrations(fsys fs.FS, dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %s: %w", dialect, err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+		versionStr, name, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(versionStr, 10, 32)
+		if !ok || name == "" || err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[uint(version)]
+		if !exists {
+			m = &Migration{Version: uint(version), Name: name}
+			byVersion[uint(version)] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest returns the version of the newest embedded migration
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the highest applied version and whether any migration was left dirty
+func (m *Migrator) Version(ctx context.Context) (version uint, dirty bool, err error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	for _, row := range applied {
+		version = max(version, row.Version)
+		dirty = dirty || row.Dirty
+	}
+	return version, dirty, nil
+}
+
+// CheckVersion returns an error unless the schema is clean and exactly at the latest version
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case dirty:
+		return fmt.Errorf("%w at version %d, fix the schema and run 'migrate force %d'", ErrDirtySchema, version, version)
+	case version < m.Latest():
+		return fmt.Errorf("database schema is at version %d but %d is required, run 'migrate up'", version, m.Latest())
+	case version > m.Latest():
+		return fmt.Errorf("database schema version %d is newer than the %d supported by this build", version, m.Latest())
+	}
+	return nil
+}
+
+// Status lists all embedded migrations and when they were applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			status.Dirty = row.Dirty
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up applies pending migrations up to and including target, 0 means the latest version.
+// It returns the number of migrations applied.
+func (m *Migrator) Up(ctx context.Context, target uint) (count int, err error) {
+	if target == 0 {
+		target = m.Latest()
+	}
+	err = m.withLock(ctx, func() error {
+		applied, err := m.clean(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > target {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.run(ctx, migration, true); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down reverts the given number of most recently applied migrations.
+// It returns the number of migrations reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (count int, err error) {
+	err = m.withLock(ctx, func() error {
+		applied, err := m.clean(ctx)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s cannot be reverted, it has no down file", migration.Version, migration.Name)
+			}
+			if err := m.run(ctx, migration, false); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Force marks all migrations up to version as applied and clears the dirty flag without running any SQL.
+// It is used after repairing a failed migration by hand. Version 0 removes all records.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	return m.withLock(ctx, func() error {
+		return m.force(ctx, version)
+	})
+}
+
+func (m *Migrator) force(ctx context.Context, version uint) error {
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("version > ?", version).Delete(&schemaMigration{}).Error; err != nil {
+			return fmt.Errorf("failed to remove migration records: %w", err)
+		}
+		if err := tx.Model(&schemaMigration{}).Where("dirty = ?", true).Update("dirty", false).Error; err != nil {
+			return fmt.Errorf("failed to clear dirty flag: %w", err)
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			row := schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+			if err := tx.Where("version = ?", migration.Version).FirstOrCreate(&row).Error; err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+			}
+		}
+		return nil
+	})
+}
+
+// withLock runs fn while holding a database-wide lock, so that instances starting at the same time
+// never apply migrations concurrently; the loser waits and then finds them applied.
+// MySQL uses GET_LOCK and PostgreSQL an advisory lock, both held by a dedicated connection and
+// released by the server if the process dies. SQLite databases are opened by a single process,
+// its write lock already serializes the migrations of that process.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	if m.dialect != DialectMySQL && m.dialect != DialectPostgres {
+		return fn()
+	}
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open migration lock connection: %w", err)
+	}
+	defer conn.Close()
+
+	lockCtx, cancel := context.WithTimeout(ctx, migrationLockTimeout)
+	defer cancel()
+	var unlock string
+	switch m.dialect {
+	case DialectMySQL:
+		var acquired sql.NullInt64
+		err = conn.QueryRowContext(lockCtx, "SELECT GET_LOCK(?, ?)", migrationLockName, int(migrationLockTimeout.Seconds())).Scan(&acquired)
+		if err == nil && acquired.Int64 != 1 {
+			err = context.DeadlineExceeded
+		}
+		unlock = "SELECT RELEASE_LOCK('" + migrationLockName + "')"
+	case DialectPostgres:
+		_, err = conn.ExecContext(lockCtx, "SELECT pg_advisory_lock(hashtext($1))", migrationLockName)
+		unlock = "SELECT pg_advisory_unlock(hashtext('" + migrationLockName + "'))"
+	}
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock %s: %w", migrationLockName, err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), unlock); err != nil {
+			log.WarnContext(ctx, "Failed to release migration lock", "error", err)
+		}
+	}()
+	return fn()
+}
+
+// run applies one migration in the given direction.
+// The migration is recorded as dirty before its SQL runs; on PostgreSQL and SQLite the SQL and
+// the final record update share a transaction, so a failure leaves the schema unchanged.
+// MySQL commits every DDL statement implicitly, a failure there leaves the record dirty.
+func (m *Migrator) run(ctx context.Context, migration Migration, up bool) error {
+	db := m.db.WithContext(ctx)
+	script, direction := migration.Up, "up"
+	if !up {
+		script, direction = migration.Down, "down"
+	}
+	log.InfoContext(ctx, "Applying migration", "version", migration.Version, "name", migration.Name, "direction", direction)
+
+	row := schemaMigration{Version: migration.Version, Name: migration.Name, Dirty: true, AppliedAt: time.Now()}
+	if err := db.Save(&row).Error; err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range splitStatements(script) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		if up {
+			return tx.Model(&row).Update("dirty", false).Error
+		}
+		return tx.Delete(&row).Error
+	})
+	if err != nil {
+		if m.dialect != DialectMySQL {
+			if up {
+				err = errors.Join(err, db.Delete(&row).Error)
+			} else {
+				err = errors.Join(err, db.Model(&row).Update("dirty", false).Error)
+			}
+		}
+		return fmt.Errorf("migration %d_%s %s failed: %w", migration.Version, migration.Name, direction, err)
+	}
+	return nil
+}
+
+// clean returns the applied migrations, refusing to continue from a dirty schema
+func (m *Migrator) clean(ctx context.Context) (map[uint]schemaMigration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range applied {
+		if row.Dirty {
+			return nil, fmt.Errorf("%w at version %d, fix the schema and run 'migrate force %d'", ErrDirtySchema, row.Version, row.Version)
+		}
+	}
+	return applied, nil
+}
+
+// applied returns the recorded migrations by version, empty if the table does not exist yet
+func (m *Migrator) applied(ctx context.Context) (map[uint]schemaMigration, error) {
+	db := m.db.WithContext(ctx)
+	applied := make(map[uint]schemaMigration)
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+	var rows []schemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	db := m.db.WithContext(ctx)
+	if db.Migrator().HasTable(&schemaMigration{}) {
+		return nil
+	}
+	if err := db.Migrator().CreateTable(&schemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migration table: %w", err)
+	}
+	return nil
+}
+
+// splitStatements splits a script on semicolons at the end of a line and drops comment lines.
+// Statements spanning several lines are kept together, semicolons inside a line are not split.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openTestSQLite opens a migrated SQLite database in a temporary directory
+func openTestSQLite(t *testing.T) (*gorm.DB, *Migrator) {
+	t.Helper()
+	db, err := openGorm(sqlite.Open(sqliteDSN(filepath.Join(t.TempDir(), "test.db"))), semconv.DBSystemSqlite, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return db, migrator
+}
+
+func TestAuditLogAppendOnly(t *testing.T) {
+	db, _ := openTestSQLite(t)
+	entry := model.AuditLog{OccurredAt: time.Now(), Action: "auth.login", Outcome: "success", ActorType: "anonymous"}
+	if err := db.Create(&entry).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Raw SQL bypasses the GORM hooks of model.AuditLog, the triggers must still reject it
+	statements := []string{
+		"UPDATE audit_log SET outcome = 'failure'",
+		"DELETE FROM audit_log",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err == nil {
+			t.Errorf("%s succeeded on the append-only audit log", statement)
+		}
+	}
+
+	var count int64
+	if err := db.Model(&model.AuditLog{}).Where("outcome = ?", "success").Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("audit log has %d unchanged entries, want 1", count)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"single statement", "CREATE TABLE a (id INT);", []string{"CREATE TABLE a (id INT);"}},
+		{"one per line", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n", []string{"CREATE TABLE a (id INT);", "CREATE TABLE b (id INT);"}},
+		{"statement over several lines", "CREATE TABLE a (\n  id INT\n);", []string{"CREATE TABLE a (\n  id INT\n);"}},
+		{"comments and blank lines dropped", "-- create a\n\nCREATE TABLE a (id INT);\n  -- done\n", []string{"CREATE TABLE a (id INT);"}},
+		{"semicolons inside a line kept", "CREATE TRIGGER t BEGIN SELECT 1; END;", []string{"CREATE TRIGGER t BEGIN SELECT 1; END;"}},
+		{"missing final semicolon", "CREATE TABLE a (id INT);\nDROP TABLE b", []string{"CREATE TABLE a (id INT);", "DROP TABLE b"}},
+		{"empty script", "\n-- nothing\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(sql string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(sql)} }
+	tests := []struct {
+		name    string
+		files   map[string]string // file name in migrations/sqlite to content
+		want    []uint            // versions in order
+		wantErr string
+	}{
+		{"sorted by version", map[string]string{
+			"0010_later.up.sql": "B;", "0002_first.up.sql": "A;", "0002_first.down.sql": "-A;",
+		}, []uint{2, 10}, ""},
+		{"not sql direction", map[string]string{"0001_init.sideways.sql": "A;"}, nil, "invalid migration file name"},
+		{"no direction", map[string]string{"0001_init.sql": "A;"}, nil, "invalid migration file name"},
+		{"version not a number", map[string]string{"v1_init.up.sql": "A;"}, nil, "invalid migration version"},
+		{"version zero", map[string]string{"0000_init.up.sql": "A;"}, nil, "invalid migration version"},
+		{"no name", map[string]string{"0001.up.sql": "A;"}, nil, "invalid migration version"},
+		{"names differ", map[string]string{"0001_init.up.sql": "A;", "0001_start.down.sql": "-A;"}, nil, "different names"},
+		{"down without up", map[string]string{"0001_init.down.sql": "-A;"}, nil, "has no up file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for name, sql := range tt.files {
+				fsys["migrations/sqlite/"+name] = file(sql)
+			}
+			migrations, err := loadMigrations(fsys, DialectSQLite)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadMigrations() = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var versions []uint
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+			}
+			if !reflect.DeepEqual(versions, tt.want) {
+				t.Errorf("versions = %v, want %v", versions, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	// Every dialect must ship the same versions under the same names
+	sqlite, err := loadMigrations(migrationFiles, DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dialect := range []string{DialectMySQL, DialectPostgres} {
+		migrations, err := loadMigrations(migrationFiles, dialect)
+		if err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+		if len(migrations) != len(sqlite) {
+			t.Fatalf("%s has %d migrations, sqlite has %d", dialect, len(migrations), len(sqlite))
+		}
+		for i, m := range migrations {
+			if m.Version != sqlite[i].Version || m.Name != sqlite[i].Name || m.Down == "" {
+				t.Errorf("%s migration %d_%s does not match sqlite %d_%s or has no down file", dialect, m.Version, m.Name, sqlite[i].Version, sqlite[i].Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+-- Initial schema: users, addresses, outbox, webhooks, audit log and login history.
+-- IF NOT EXISTS lets databases created by earlier releases, which created missing tables on startup, adopt this version.
+
+CREATE TABLE IF NOT EXISTS `user` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `email` varchar(100) NOT NULL,
+  `password_hash` varchar(255) NOT NULL,
+  `first_name` varchar(50),
+  `last_name` varchar(50),
+  PRIMARY KEY (`id`),
+  INDEX `idx_user_deleted_at` (`deleted_at`),
+  INDEX `idx_user_email` (`email`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `address` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `street` varchar(255) NOT NULL,
+  `city` varchar(100) NOT NULL,
+  `state` varchar(100),
+  `postal_code` varchar(20) NOT NULL,
+  `country` varchar(100) NOT NULL,
+  `is_default` boolean DEFAULT false,
+  PRIMARY KEY (`id`),
+  INDEX `idx_address_deleted_at` (`deleted_at`),
+  INDEX `idx_address_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `outbox_event` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `event_id` varchar(36) NOT NULL,
+  `event_type` varchar(100) NOT NULL,
+  `aggregate_type` varchar(50) NOT NULL,
+  `aggregate_id` varchar(64) NOT NULL,
+  `payload` text NOT NULL,
+  `occurred_at` datetime(3) NOT NULL,
+  `published_at` datetime(3) NULL,
+  `attempts` bigint NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime(3) NOT NULL,
+  `last_error` text,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_outbox_event_aggregate_id` (`aggregate_id`),
+  INDEX `idx_outbox_event_published_at` (`published_at`),
+  INDEX `idx_outbox_event_next_attempt_at` (`next_attempt_at`),
+  UNIQUE INDEX `idx_outbox_event_event_id` (`event_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `webhook_subscription` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `url` varchar(2048) NOT NULL,
+  `event_types` varchar(1024) NOT NULL,
+  `secret` varchar(128) NOT NULL,
+  `description` varchar(255),
+  `active` boolean NOT NULL DEFAULT true,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `webhook_delivery` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `subscription_id` bigint unsigned NOT NULL,
+  `event_id` varchar(36) NOT NULL,
+  `event_type` varchar(100) NOT NULL,
+  `payload` text NOT NULL,
+  `status` varchar(20) NOT NULL,
+  `attempts` bigint NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime(3) NOT NULL,
+  `last_status_code` bigint NOT NULL DEFAULT 0,
+  `last_error` text,
+  `delivered_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_webhook_delivery_event` (`subscription_id`,`event_id`),
+  INDEX `idx_webhook_delivery_due` (`status`,`next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `webhook_attempt` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `delivery_id` bigint unsigned NOT NULL,
+  `status_code` bigint NOT NULL DEFAULT 0,
+  `duration_ms` bigint NOT NULL,
+  `error` text,
+  `response_body` text,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_webhook_attempt_delivery_id` (`delivery_id`),
+  INDEX `idx_webhook_attempt_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `audit_log` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `occurred_at` datetime(3) NOT NULL,
+  `action` varchar(64) NOT NULL,
+  `outcome` varchar(16) NOT NULL,
+  `actor_id` bigint unsigned,
+  `target_user_id` bigint unsigned,
+  `ip` varchar(45),
+  `user_agent` varchar(512),
+  `request_id` varchar(128),
+  `changes` text,
+  `details` text,
+  PRIMARY KEY (`id`),
+  INDEX `idx_audit_log_occurred_at` (`occurred_at`),
+  INDEX `idx_audit_log_action` (`action`),
+  INDEX `idx_audit_log_actor_id` (`actor_id`),
+  INDEX `idx_audit_log_target_user_id` (`target_user_id`),
+  INDEX `idx_audit_log_request_id` (`request_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `login_history` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `success` boolean NOT NULL,
+  `failure_reason` varchar(50),
+  `ip` varchar(45),
+  `user_agent` varchar(512),
+  `device_id` varchar(64),
+  `country` varchar(2),
+  `city` varchar(100),
+  `new_device` boolean NOT NULL DEFAULT false,
+  `new_country` boolean NOT NULL DEFAULT false,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_login_history_user` (`user_id`,`created_at`),
+  INDEX `idx_login_history_device_id` (`device_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+ALTER TABLE `user`
+  DROP INDEX `idx_user_active_email`,
+  DROP COLUMN `active_email`;
@@ -0,0 +1,9 @@
+-- Emails are unique, ignoring case, among users that are not soft-deleted.
+-- MySQL has no partial indexes: the generated column is NULL for deleted users and NULLs never collide.
+-- Fails if existing accounts only differ in case, merge or rename them before migrating.
+
+UPDATE `user` SET `email` = LOWER(TRIM(`email`));
+
+ALTER TABLE `user`
+  ADD COLUMN `active_email` varchar(100) GENERATED ALWAYS AS (IF(`deleted_at` IS NULL, LOWER(`email`), NULL)) VIRTUAL,
+  ADD UNIQUE INDEX `idx_user_active_email` (`active_email`);
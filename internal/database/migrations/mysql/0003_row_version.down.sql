@@ -0,0 +1,2 @@
+ALTER TABLE `address` DROP COLUMN `version`;
+ALTER TABLE `user` DROP COLUMN `version`;
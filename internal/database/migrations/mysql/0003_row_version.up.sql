@@ -0,0 +1,5 @@
+-- Row versions for optimistic concurrency: every update of a user or address increments its version,
+-- and updates conditional on the version a client read fail instead of overwriting a concurrent change.
+
+ALTER TABLE `user` ADD COLUMN `version` bigint unsigned NOT NULL DEFAULT 1;
+ALTER TABLE `address` ADD COLUMN `version` bigint unsigned NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+ALTER TABLE `address`
+  DROP INDEX `idx_address_user_default`,
+  DROP COLUMN `default_type`,
+  DROP COLUMN `label`,
+  DROP COLUMN `type`;
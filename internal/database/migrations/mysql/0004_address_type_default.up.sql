@@ -0,0 +1,27 @@
+-- Address types and labels, and at most one default address per user and type.
+-- Existing addresses become shipping addresses. Users with several defaults keep the most recent one,
+-- users with addresses but no default get their most recent address as default.
+-- MySQL has no partial indexes: the generated column holds the type of default addresses only and NULLs never collide.
+
+ALTER TABLE `address`
+  ADD COLUMN `type` varchar(16) NOT NULL DEFAULT 'shipping',
+  ADD COLUMN `label` varchar(50);
+
+UPDATE `address` a
+JOIN (
+  SELECT `user_id`, `type`, MAX(`id`) AS `keep_id` FROM `address`
+  WHERE `deleted_at` IS NULL AND `is_default` GROUP BY `user_id`, `type`
+) d ON a.`user_id` = d.`user_id` AND a.`type` = d.`type`
+SET a.`is_default` = false, a.`version` = a.`version` + 1
+WHERE a.`deleted_at` IS NULL AND a.`is_default` AND a.`id` <> d.`keep_id`;
+
+UPDATE `address` a
+JOIN (
+  SELECT MAX(`id`) AS `id` FROM `address` WHERE `deleted_at` IS NULL
+  GROUP BY `user_id`, `type` HAVING MAX(COALESCE(`is_default`, false)) = false
+) d ON a.`id` = d.`id`
+SET a.`is_default` = true, a.`version` = a.`version` + 1;
+
+ALTER TABLE `address`
+  ADD COLUMN `default_type` varchar(16) GENERATED ALWAYS AS (IF(`is_default` AND `deleted_at` IS NULL, `type`, NULL)) VIRTUAL,
+  ADD UNIQUE INDEX `idx_address_user_default` (`user_id`, `default_type`);
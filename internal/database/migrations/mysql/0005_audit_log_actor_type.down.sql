@@ -0,0 +1,2 @@
+DROP INDEX `idx_audit_log_actor_type` ON `audit_log`;
+ALTER TABLE `audit_log` DROP COLUMN `actor_type`;
@@ -0,0 +1,6 @@
+-- Classify the actor of audit log entries: an authenticated user, a trusted internal system
+-- (gRPC callers) or an anonymous request. Entries with an actor ID were made by users.
+
+ALTER TABLE `audit_log` ADD COLUMN `actor_type` varchar(16) NOT NULL DEFAULT 'anonymous';
+CREATE INDEX `idx_audit_log_actor_type` ON `audit_log` (`actor_type`);
+UPDATE `audit_log` SET `actor_type` = 'user' WHERE `actor_id` IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Make the audit log append-only in the database, so that raw SQL cannot rewrite history either.
+-- Creating triggers with binary logging enabled requires SUPER or log_bin_trust_function_creators.
+
+CREATE TRIGGER `audit_log_no_update` BEFORE UPDATE ON `audit_log` FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit log entries are append-only';
+CREATE TRIGGER `audit_log_no_delete` BEFORE DELETE ON `audit_log` FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit log entries are append-only';
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "login_history";
+DROP TABLE IF EXISTS "audit_log";
+DROP TABLE IF EXISTS "webhook_attempt";
+DROP TABLE IF EXISTS "webhook_delivery";
+DROP TABLE IF EXISTS "webhook_subscription";
+DROP TABLE IF EXISTS "outbox_event";
+DROP TABLE IF EXISTS "address";
+DROP TABLE IF EXISTS "user";
@@ -0,0 +1,135 @@
+-- Initial schema: users, addresses, outbox, webhooks, audit log and login history.
+-- IF NOT EXISTS lets databases created by earlier releases, which created missing tables on startup, adopt this version.
+
+CREATE TABLE IF NOT EXISTS "user" (
+  "id" bigserial,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "email" varchar(100) NOT NULL,
+  "password_hash" varchar(255) NOT NULL,
+  "first_name" varchar(50),
+  "last_name" varchar(50),
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_email" ON "user" ("email");
+CREATE INDEX IF NOT EXISTS "idx_user_deleted_at" ON "user" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "address" (
+  "id" bigserial,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "user_id" bigint NOT NULL,
+  "street" varchar(255) NOT NULL,
+  "city" varchar(100) NOT NULL,
+  "state" varchar(100),
+  "postal_code" varchar(20) NOT NULL,
+  "country" varchar(100) NOT NULL,
+  "is_default" boolean DEFAULT false,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_address_user_id" ON "address" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_address_deleted_at" ON "address" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "outbox_event" (
+  "id" bigserial,
+  "event_id" varchar(36) NOT NULL,
+  "event_type" varchar(100) NOT NULL,
+  "aggregate_type" varchar(50) NOT NULL,
+  "aggregate_id" varchar(64) NOT NULL,
+  "payload" text NOT NULL,
+  "occurred_at" timestamptz NOT NULL,
+  "published_at" timestamptz,
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL,
+  "last_error" text,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_outbox_event_next_attempt_at" ON "outbox_event" ("next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_event_published_at" ON "outbox_event" ("published_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_event_aggregate_id" ON "outbox_event" ("aggregate_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_outbox_event_event_id" ON "outbox_event" ("event_id");
+
+CREATE TABLE IF NOT EXISTS "webhook_subscription" (
+  "id" bigserial,
+  "url" varchar(2048) NOT NULL,
+  "event_types" varchar(1024) NOT NULL,
+  "secret" varchar(128) NOT NULL,
+  "description" varchar(255),
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_delivery" (
+  "id" bigserial,
+  "subscription_id" bigint NOT NULL,
+  "event_id" varchar(36) NOT NULL,
+  "event_type" varchar(100) NOT NULL,
+  "payload" text NOT NULL,
+  "status" varchar(20) NOT NULL,
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL,
+  "last_status_code" bigint NOT NULL DEFAULT 0,
+  "last_error" text,
+  "delivered_at" timestamptz,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_delivery_due" ON "webhook_delivery" ("status","next_attempt_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_webhook_delivery_event" ON "webhook_delivery" ("subscription_id","event_id");
+
+CREATE TABLE IF NOT EXISTS "webhook_attempt" (
+  "id" bigserial,
+  "delivery_id" bigint NOT NULL,
+  "status_code" bigint NOT NULL DEFAULT 0,
+  "duration_ms" bigint NOT NULL,
+  "error" text,
+  "response_body" text,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_attempt_delivery_id" ON "webhook_attempt" ("delivery_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_attempt_created_at" ON "webhook_attempt" ("created_at");
+
+CREATE TABLE IF NOT EXISTS "audit_log" (
+  "id" bigserial,
+  "occurred_at" timestamptz NOT NULL,
+  "action" varchar(64) NOT NULL,
+  "outcome" varchar(16) NOT NULL,
+  "actor_id" bigint,
+  "target_user_id" bigint,
+  "ip" varchar(45),
+  "user_agent" varchar(512),
+  "request_id" varchar(128),
+  "changes" text,
+  "details" text,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_log_request_id" ON "audit_log" ("request_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_log_target_user_id" ON "audit_log" ("target_user_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_log_actor_id" ON "audit_log" ("actor_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_log_action" ON "audit_log" ("action");
+CREATE INDEX IF NOT EXISTS "idx_audit_log_occurred_at" ON "audit_log" ("occurred_at");
+
+CREATE TABLE IF NOT EXISTS "login_history" (
+  "id" bigserial,
+  "user_id" bigint NOT NULL,
+  "success" boolean NOT NULL,
+  "failure_reason" varchar(50),
+  "ip" varchar(45),
+  "user_agent" varchar(512),
+  "device_id" varchar(64),
+  "country" varchar(2),
+  "city" varchar(100),
+  "new_device" boolean NOT NULL DEFAULT false,
+  "new_country" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz,
+  PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_login_history_device_id" ON "login_history" ("device_id");
+CREATE INDEX IF NOT EXISTS "idx_login_history_user" ON "login_history" ("user_id","created_at");
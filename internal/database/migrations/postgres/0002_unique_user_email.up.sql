@@ -0,0 +1,6 @@
+-- Emails are unique, ignoring case, among users that are not soft-deleted.
+-- Fails if existing accounts only differ in case, merge or rename them before migrating.
+
+UPDATE "user" SET "email" = LOWER(TRIM("email"));
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_active_email" ON "user" (LOWER("email")) WHERE "deleted_at" IS NULL;
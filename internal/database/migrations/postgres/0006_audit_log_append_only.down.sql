@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS "audit_log_no_truncate" ON "audit_log";
+DROP TRIGGER IF EXISTS "audit_log_no_change" ON "audit_log";
+DROP FUNCTION IF EXISTS "audit_log_append_only"();
@@ -0,0 +1,8 @@
+-- Make the audit log append-only in the database, so that raw SQL cannot rewrite history either.
+-- The function body stays on one line because migration statements are split at line-final semicolons.
+
+CREATE OR REPLACE FUNCTION "audit_log_append_only"() RETURNS trigger LANGUAGE plpgsql AS $$ BEGIN RAISE EXCEPTION 'audit log entries are append-only' USING ERRCODE = 'insufficient_privilege'; END $$;
+DROP TRIGGER IF EXISTS "audit_log_no_change" ON "audit_log";
+CREATE TRIGGER "audit_log_no_change" BEFORE UPDATE OR DELETE ON "audit_log" FOR EACH ROW EXECUTE FUNCTION "audit_log_append_only"();
+DROP TRIGGER IF EXISTS "audit_log_no_truncate" ON "audit_log";
+CREATE TRIGGER "audit_log_no_truncate" BEFORE TRUNCATE ON "audit_log" FOR EACH STATEMENT EXECUTE FUNCTION "audit_log_append_only"();
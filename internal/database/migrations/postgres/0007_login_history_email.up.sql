@@ -0,0 +1,4 @@
+-- Failed logins for emails without an account are recorded with user_id 0 and the attempted email,
+-- redacted to its first character and domain, so that credential stuffing shows up in the login history.
+
+ALTER TABLE "login_history" ADD COLUMN IF NOT EXISTS "email" varchar(255);
@@ -0,0 +1,127 @@
+-- Initial schema: users, addresses, outbox, webhooks, audit log and login history.
+-- IF NOT EXISTS lets databases created by earlier releases, which created missing tables on startup, adopt this version.
+
+CREATE TABLE IF NOT EXISTS `user` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `email` varchar(100) NOT NULL,
+  `password_hash` varchar(255) NOT NULL,
+  `first_name` varchar(50),
+  `last_name` varchar(50)
+);
+CREATE INDEX IF NOT EXISTS `idx_user_email` ON `user` (`email`);
+CREATE INDEX IF NOT EXISTS `idx_user_deleted_at` ON `user` (`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `address` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `user_id` integer NOT NULL,
+  `street` varchar(255) NOT NULL,
+  `city` varchar(100) NOT NULL,
+  `state` varchar(100),
+  `postal_code` varchar(20) NOT NULL,
+  `country` varchar(100) NOT NULL,
+  `is_default` numeric DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS `idx_address_user_id` ON `address` (`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_address_deleted_at` ON `address` (`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `outbox_event` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `event_id` varchar(36) NOT NULL,
+  `event_type` varchar(100) NOT NULL,
+  `aggregate_type` varchar(50) NOT NULL,
+  `aggregate_id` varchar(64) NOT NULL,
+  `payload` text NOT NULL,
+  `occurred_at` datetime NOT NULL,
+  `published_at` datetime,
+  `attempts` integer NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime NOT NULL,
+  `last_error` text,
+  `created_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_outbox_event_next_attempt_at` ON `outbox_event` (`next_attempt_at`);
+CREATE INDEX IF NOT EXISTS `idx_outbox_event_published_at` ON `outbox_event` (`published_at`);
+CREATE INDEX IF NOT EXISTS `idx_outbox_event_aggregate_id` ON `outbox_event` (`aggregate_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_outbox_event_event_id` ON `outbox_event` (`event_id`);
+
+CREATE TABLE IF NOT EXISTS `webhook_subscription` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `url` varchar(2048) NOT NULL,
+  `event_types` varchar(1024) NOT NULL,
+  `secret` varchar(128) NOT NULL,
+  `description` varchar(255),
+  `active` numeric NOT NULL DEFAULT true,
+  `created_at` datetime,
+  `updated_at` datetime
+);
+
+CREATE TABLE IF NOT EXISTS `webhook_delivery` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `subscription_id` integer NOT NULL,
+  `event_id` varchar(36) NOT NULL,
+  `event_type` varchar(100) NOT NULL,
+  `payload` text NOT NULL,
+  `status` varchar(20) NOT NULL,
+  `attempts` integer NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime NOT NULL,
+  `last_status_code` integer NOT NULL DEFAULT 0,
+  `last_error` text,
+  `delivered_at` datetime,
+  `created_at` datetime,
+  `updated_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_webhook_delivery_due` ON `webhook_delivery` (`status`,`next_attempt_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_webhook_delivery_event` ON `webhook_delivery` (`subscription_id`,`event_id`);
+
+CREATE TABLE IF NOT EXISTS `webhook_attempt` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `delivery_id` integer NOT NULL,
+  `status_code` integer NOT NULL DEFAULT 0,
+  `duration_ms` integer NOT NULL,
+  `error` text,
+  `response_body` text,
+  `created_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_webhook_attempt_created_at` ON `webhook_attempt` (`created_at`);
+CREATE INDEX IF NOT EXISTS `idx_webhook_attempt_delivery_id` ON `webhook_attempt` (`delivery_id`);
+
+CREATE TABLE IF NOT EXISTS `audit_log` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `occurred_at` datetime NOT NULL,
+  `action` varchar(64) NOT NULL,
+  `outcome` varchar(16) NOT NULL,
+  `actor_id` integer,
+  `target_user_id` integer,
+  `ip` varchar(45),
+  `user_agent` varchar(512),
+  `request_id` varchar(128),
+  `changes` text,
+  `details` text
+);
+CREATE INDEX IF NOT EXISTS `idx_audit_log_target_user_id` ON `audit_log` (`target_user_id`);
+CREATE INDEX IF NOT EXISTS `idx_audit_log_actor_id` ON `audit_log` (`actor_id`);
+CREATE INDEX IF NOT EXISTS `idx_audit_log_action` ON `audit_log` (`action`);
+CREATE INDEX IF NOT EXISTS `idx_audit_log_occurred_at` ON `audit_log` (`occurred_at`);
+CREATE INDEX IF NOT EXISTS `idx_audit_log_request_id` ON `audit_log` (`request_id`);
+
+CREATE TABLE IF NOT EXISTS `login_history` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `success` numeric NOT NULL,
+  `failure_reason` varchar(50),
+  `ip` varchar(45),
+  `user_agent` varchar(512),
+  `device_id` varchar(64),
+  `country` varchar(2),
+  `city` varchar(100),
+  `new_device` numeric NOT NULL DEFAULT false,
+  `new_country` numeric NOT NULL DEFAULT false,
+  `created_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_login_history_device_id` ON `login_history` (`device_id`);
+CREATE INDEX IF NOT EXISTS `idx_login_history_user` ON `login_history` (`user_id`,`created_at`);
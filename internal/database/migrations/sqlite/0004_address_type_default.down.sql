@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_address_user_default`;
+ALTER TABLE `address` DROP COLUMN `label`;
+ALTER TABLE `address` DROP COLUMN `type`;
@@ -0,0 +1,20 @@
+-- Address types and labels, and at most one default address per user and type.
+-- Existing addresses become shipping addresses. Users with several defaults keep the most recent one,
+-- users with addresses but no default get their most recent address as default.
+
+ALTER TABLE `address` ADD COLUMN `type` varchar(16) NOT NULL DEFAULT 'shipping';
+ALTER TABLE `address` ADD COLUMN `label` varchar(50);
+
+UPDATE `address` SET `is_default` = false, `version` = `version` + 1
+WHERE `deleted_at` IS NULL AND `is_default` AND `id` <> (
+  SELECT MAX(d.`id`) FROM `address` d
+  WHERE d.`user_id` = `address`.`user_id` AND d.`type` = `address`.`type` AND d.`deleted_at` IS NULL AND d.`is_default`
+);
+
+UPDATE `address` SET `is_default` = true, `version` = `version` + 1
+WHERE `id` IN (
+  SELECT MAX(`id`) FROM `address` WHERE `deleted_at` IS NULL
+  GROUP BY `user_id`, `type` HAVING MAX(COALESCE(`is_default`, false)) = false
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_address_user_default` ON `address` (`user_id`, `type`) WHERE `is_default` AND `deleted_at` IS NULL;
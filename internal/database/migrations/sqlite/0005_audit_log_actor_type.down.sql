@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_audit_log_actor_type`;
+ALTER TABLE `audit_log` DROP COLUMN `actor_type`;
@@ -0,0 +1,5 @@
+-- Make the audit log append-only in the database, so that raw SQL cannot rewrite history either.
+-- Trigger bodies stay on one line because migration statements are split at line-final semicolons.
+
+CREATE TRIGGER IF NOT EXISTS `audit_log_no_update` BEFORE UPDATE ON `audit_log` BEGIN SELECT RAISE(ABORT, 'audit log entries are append-only'); END;
+CREATE TRIGGER IF NOT EXISTS `audit_log_no_delete` BEFORE DELETE ON `audit_log` BEGIN SELECT RAISE(ABORT, 'audit log entries are append-only'); END;
@@ -0,0 +1 @@
+ALTER TABLE `login_history` DROP COLUMN `email`;
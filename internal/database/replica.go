@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"gorm.io/gorm"
+)
+
+// replica is one read replica connection and its health
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// setHealthy records the health of the replica and logs transitions
+func (r *replica) setHealthy(ctx context.Context, healthy bool, err error) {
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		metrics.DBReplicaUp.WithLabelValues(r.name).Set(1)
+		log.InfoContext(ctx, "Read replica is healthy, routing reads to it", "replica", r.name)
+	} else {
+		metrics.DBReplicaUp.WithLabelValues(r.name).Set(0)
+		log.WarnContext(ctx, "Read replica ejected", "replica", r.name, "error", err)
+	}
+}
+
+// replicaPool is a gorm.ConnPool that spreads queries round-robin over healthy replicas.
+// Statements that may write always go to the primary, and reads fall back to the primary
+// when no replica is healthy or the chosen replica fails with a connection error.
+type replicaPool struct {
+	primary  *sql.DB
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+var _ gorm.ConnPool = (*replicaPool)(nil)
+
+// pick returns the next healthy replica, nil when all are ejected
+func (p *replicaPool) pick() *replica {
+	n := uint64(len(p.replicas))
+	start := p.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if r := p.replicas[(start+i)%n]; r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+func (p *replicaPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.primary.PrepareContext(ctx, query)
+}
+
+func (p *replicaPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.primary.ExecContext(ctx, query, args...)
+}
+
+func (p *replicaPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if r := p.pick(); r != nil {
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err == nil || !p.failover(ctx, r, err) {
+			metrics.DBReplicaReadsTotal.WithLabelValues("replica").Inc()
+			return rows, err
+		}
+	}
+	metrics.DBReplicaReadsTotal.WithLabelValues("primary").Inc()
+	return p.primary.QueryContext(ctx, query, args...)
+}
+
+func (p *replicaPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if r := p.pick(); r != nil {
+		row := r.db.QueryRowContext(ctx, query, args...)
+		if err := row.Err(); err == nil || !p.failover(ctx, r, err) {
+			metrics.DBReplicaReadsTotal.WithLabelValues("replica").Inc()
+			return row
+		}
+	}
+	metrics.DBReplicaReadsTotal.WithLabelValues("primary").Inc()
+	return p.primary.QueryRowContext(ctx, query, args...)
+}
+
+// failover ejects the replica and reports whether the query should be retried on the primary.
+// Only connection failures count, SQL errors would fail on the primary as well.
+func (p *replicaPool) failover(ctx context.Context, r *replica, err error) bool {
+	var netErr net.Error
+	if ctx.Err() != nil || !(errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr)) {
+		return false
+	}
+	r.setHealthy(ctx, false, err)
+	return true
+}
+
+// ReplicaSet routes reads to read replicas and ejects replicas that fail their health checks
+type ReplicaSet struct {
+	db   *gorm.DB
+	pool *replicaPool
+}
+
+// OpenReplicas connects to the read replicas of primary.
+// The replicas must use the same database system as the primary; a replica that cannot be
+// reached at startup stays ejected until a later health check succeeds.
+func OpenReplicas(primary *gorm.DB, replicaURLs []string, slowQueryThreshold time.Duration) (*ReplicaSet, error) {
+	primarySQL, err := primary.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get generic database object: %w", err)
+	}
+
+	if len(replicaURLs) == 0 {
+		return nil, errors.New("no replica URLs configured")
+	}
+
+	pool := &replicaPool{primary: primarySQL}
+	var replicaDialect dialect
+	for i, url := range replicaURLs {
+		dialect, err := parseDatabaseURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("replica %d: %w", i, err)
+		}
+		if dialect.name != primary.Dialector.Name() {
+			return nil, fmt.Errorf("replica %d is %s but the primary is %s", i, dialect.name, primary.Dialector.Name())
+		}
+		replicaDialect = dialect
+		// sql.Open does not connect, so an unreachable replica does not prevent startup
+		sqlDB, err := sql.Open(dialect.driverName, dialect.dsn)
+		if err != nil {
+			return nil, fmt.Errorf("replica %d: %w", i, err)
+		}
+		configurePool(sqlDB, dialect)
+		r := &replica{name: strconv.Itoa(i), db: sqlDB}
+		if err := metrics.RegisterDBStats(sqlDB, dialect.name+"_replica_"+r.name); err != nil {
+			return nil, err
+		}
+		pool.replicas = append(pool.replicas, r)
+	}
+
+	set := &ReplicaSet{pool: pool}
+	for _, r := range pool.replicas {
+		metrics.DBReplicaUp.WithLabelValues(r.name).Set(0)
+	}
+	set.check(context.Background(), 5*time.Second)
+
+	// All replicas share the dialect of the primary, the reads DB speaks it over the routing pool
+	set.db, err = openGorm(replicaDialect.withConn(pool), replicaDialect.system, slowQueryThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replica reads: %w", err)
+	}
+
+	healthy := 0
+	for _, r := range pool.replicas {
+		if r.healthy.Load() {
+			healthy++
+		} else {
+			log.Warn("Read replica is not reachable, reads use the primary until it recovers", "replica", r.name)
+		}
+	}
+	log.Info("Read replicas configured.", "replicas", len(pool.replicas), "healthy", healthy)
+	return set, nil
+}
+
+// DB returns a *gorm.DB for reads that tolerate replication lag.
+// Writes made through it still go to the primary, transactions are not supported.
+func (s *ReplicaSet) DB() *gorm.DB {
+	return s.db
+}
+
+// Run pings every replica each interval until ctx is cancelled.
+// Failing replicas are ejected and readmitted once a ping succeeds.
+func (s *ReplicaSet) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.check(ctx, interval)
+		}
+	}
+}
+
+// check pings every replica, each ping bounded by timeout
+func (s *ReplicaSet) check(ctx context.Context, timeout time.Duration) {
+	for _, r := range s.pool.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := r.db.PingContext(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		r.setHealthy(ctx, err == nil, err)
+	}
+}
+
+// Close closes the replica connections
+func (s *ReplicaSet) Close() error {
+	var errs []error
+	for _, r := range s.pool.replicas {
+		errs = append(errs, r.db.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	eventpb "github.com/blackwatch66/user-microservice/api/events/proto"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SchemaVersion is the current version of the envelope format, bumped on incompatible changes
+const SchemaVersion = 1
+
+// Source identifies this service as the producer of events
+const Source = "user-service"
+
+// Supported envelope encodings
+const (
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+)
+
+// Content types of encoded envelopes, carried in broker headers so consumers can decode them
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// Envelope wraps every published event with the metadata consumers need for routing and deduplication
+type Envelope struct {
+	SchemaVersion   int             `json:"schema_version"`
+	ID              string          `json:"id"`
+	Type            string          `json:"type"`
+	Source          string          `json:"source"`
+	AggregateType   string          `json:"aggregate_type"`
+	AggregateID     string          `json:"aggregate_id"`
+	OccurredAt      time.Time       `json:"occurred_at"`
+	DataContentType string          `json:"data_content_type"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// NewEnvelope builds the envelope of an event stored in the outbox
+func NewEnvelope(event *model.OutboxEvent) *Envelope {
+	return &Envelope{
+		SchemaVersion:   SchemaVersion,
+		ID:              event.EventID,
+		Type:            event.EventType,
+		Source:          Source,
+		AggregateType:   event.AggregateType,
+		AggregateID:     event.AggregateID,
+		OccurredAt:      event.OccurredAt.UTC(),
+		DataContentType: ContentTypeJSON,
+		Data:            json.RawMessage(event.Payload),
+	}
+}
+
+// Encoder serializes envelopes for transport
+type Encoder interface {
+	Encode(envelope *Envelope) ([]byte, error)
+	ContentType() string
+}
+
+// NewEncoder returns the encoder for the given encoding name
+func NewEncoder(encoding string) (Encoder, error) {
+	switch encoding {
+	case EncodingJSON, "":
+		return jsonEncoder{}, nil
+	case EncodingProtobuf:
+		return protobufEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported event encoding: %s", encoding)
+	}
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) Encode(envelope *Envelope) ([]byte, error) {
+	return json.Marshal(envelope)
+}
+
+func (jsonEncoder) ContentType() string {
+	return ContentTypeJSON
+}
+
+type protobufEncoder struct{}
+
+func (protobufEncoder) Encode(envelope *Envelope) ([]byte, error) {
+	return proto.Marshal(&eventpb.EventEnvelope{
+		SchemaVersion:   uint32(envelope.SchemaVersion),
+		Id:              envelope.ID,
+		Type:            envelope.Type,
+		Source:          envelope.Source,
+		AggregateType:   envelope.AggregateType,
+		AggregateId:     envelope.AggregateID,
+		OccurredAt:      timestamppb.New(envelope.OccurredAt),
+		DataContentType: envelope.DataContentType,
+		Data:            envelope.Data,
+	})
+}
+
+func (protobufEncoder) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+// DecodeEnvelope parses an envelope encoded with the encoder of contentType, for consumers of the events
+func DecodeEnvelope(contentType string, payload []byte) (*Envelope, error) {
+	switch contentType {
+	case ContentTypeJSON:
+		var envelope Envelope
+		if err := json.Unmarshal(payload, &envelope); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON envelope: %w", err)
+		}
+		return &envelope, nil
+	case ContentTypeProtobuf:
+		var msg eventpb.EventEnvelope
+		if err := proto.Unmarshal(payload, &msg); err != nil {
+			return nil, fmt.Errorf("failed to decode protobuf envelope: %w", err)
+		}
+		return &Envelope{
+			SchemaVersion:   int(msg.SchemaVersion),
+			ID:              msg.Id,
+			Type:            msg.Type,
+			Source:          msg.Source,
+			AggregateType:   msg.AggregateType,
+			AggregateID:     msg.AggregateId,
+			OccurredAt:      msg.OccurredAt.AsTime(),
+			DataContentType: msg.DataContentType,
+			Data:            json.RawMessage(msg.Data),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported envelope content type: %s", contentType)
+	}
+}
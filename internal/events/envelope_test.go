@@ -0,0 +1,102 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	payload, err := json.Marshal(AddressDeleted{UserID: 42, AddressID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewEnvelope(&model.OutboxEvent{
+		EventID:       "5f0c6c1e-8d0a-4c1b-9a57-1d2f1f6b7a10",
+		EventType:     TypeAddressDeleted,
+		AggregateType: AggregateUser,
+		AggregateID:   "42",
+		Payload:       string(payload),
+		OccurredAt:    time.Date(2025, 4, 21, 10, 32, 49, 971000000, time.FixedZone("CST", 8*3600)),
+	})
+
+	tests := []struct {
+		encoding    string
+		contentType string
+	}{
+		{EncodingJSON, ContentTypeJSON},
+		{"", ContentTypeJSON},
+		{EncodingProtobuf, ContentTypeProtobuf},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			encoder, err := NewEncoder(tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encoder.ContentType() != tt.contentType {
+				t.Fatalf("ContentType() = %q, want %q", encoder.ContentType(), tt.contentType)
+			}
+			encoded, err := encoder.Encode(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := DecodeEnvelope(tt.contentType, encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.OccurredAt.Equal(want.OccurredAt) {
+				t.Errorf("OccurredAt = %v, want %v", got.OccurredAt, want.OccurredAt)
+			}
+			got.OccurredAt = want.OccurredAt
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("decoded envelope = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestNewEnvelope(t *testing.T) {
+	env := NewEnvelope(&model.OutboxEvent{
+		EventID:       "id",
+		EventType:     TypeUserRegistered,
+		AggregateType: AggregateUser,
+		AggregateID:   "1",
+		Payload:       `{"user_id":1}`,
+		OccurredAt:    time.Date(2025, 1, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*3600)),
+	})
+	if env.SchemaVersion != SchemaVersion || env.Source != Source || env.DataContentType != ContentTypeJSON {
+		t.Errorf("envelope metadata = %d %q %q", env.SchemaVersion, env.Source, env.DataContentType)
+	}
+	if env.OccurredAt.Location() != time.UTC {
+		t.Errorf("OccurredAt is in %v, want UTC", env.OccurredAt.Location())
+	}
+	if string(env.Data) != `{"user_id":1}` {
+		t.Errorf("Data = %s", env.Data)
+	}
+}
+
+func TestEncodingErrors(t *testing.T) {
+	if _, err := NewEncoder("avro"); err == nil {
+		t.Error("NewEncoder(avro) succeeded")
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		payload     []byte
+	}{
+		{"unknown content type", "application/avro", []byte("{}")},
+		{"invalid JSON", ContentTypeJSON, []byte("{")},
+		{"invalid protobuf", ContentTypeProtobuf, []byte{0xff, 0xff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeEnvelope(tt.contentType, tt.payload); err == nil {
+				t.Error("DecodeEnvelope succeeded")
+			}
+		})
+	}
+}
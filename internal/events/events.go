@@ -0,0 +1,138 @@
+package events
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+// Event types emitted by the user service
+const (
+	TypeUserRegistered = "user.registered"
+	TypeProfileUpdated = "user.profile_updated"
+	TypeAddressAdded   = "user.address_added"
+	TypeAddressUpdated = "user.address_updated"
+	TypeAddressDeleted = "user.address_deleted"
+	TypeAddressMerged  = "user.address_merged"
+	TypeNewDeviceLogin = "user.new_device_login"
+)
+
+// AggregateUser is the aggregate type of every event, all events are keyed by user ID
+const AggregateUser = "user"
+
+// Event is a domain event that can be written to the outbox
+type Event interface {
+	// EventType returns one of the Type* constants
+	EventType() string
+	// AggregateID returns the ID of the user the event belongs to
+	AggregateID() string
+}
+
+// UserRegistered is emitted after a new account has been created
+type UserRegistered struct {
+	UserID       uint      `json:"user_id"`
+	Email        string    `json:"email"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+func (e UserRegistered) EventType() string   { return TypeUserRegistered }
+func (e UserRegistered) AggregateID() string { return userAggregateID(e.UserID) }
+
+// ProfileUpdated is emitted after a user's profile fields have changed
+type ProfileUpdated struct {
+	UserID    uint      `json:"user_id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (e ProfileUpdated) EventType() string   { return TypeProfileUpdated }
+func (e ProfileUpdated) AggregateID() string { return userAggregateID(e.UserID) }
+
+// Address is the address representation carried by address events
+type Address struct {
+	ID         uint   `json:"id"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	IsDefault  bool   `json:"is_default"`
+	Type       string `json:"type"`
+	Label      string `json:"label,omitempty"`
+}
+
+// NewAddress converts a stored address to its event representation
+func NewAddress(addr model.Address) Address {
+	return Address{
+		ID:         addr.ID,
+		Street:     addr.Street,
+		City:       addr.City,
+		State:      addr.State,
+		PostalCode: addr.PostalCode,
+		Country:    addr.Country,
+		IsDefault:  addr.IsDefault,
+		Type:       addr.Type,
+		Label:      addr.Label,
+	}
+}
+
+// AddressAdded is emitted after an address has been added to a user's address book
+type AddressAdded struct {
+	UserID  uint    `json:"user_id"`
+	Address Address `json:"address"`
+}
+
+func (e AddressAdded) EventType() string   { return TypeAddressAdded }
+func (e AddressAdded) AggregateID() string { return userAggregateID(e.UserID) }
+
+// AddressUpdated is emitted after an address has been modified
+type AddressUpdated struct {
+	UserID  uint    `json:"user_id"`
+	Address Address `json:"address"`
+}
+
+func (e AddressUpdated) EventType() string   { return TypeAddressUpdated }
+func (e AddressUpdated) AggregateID() string { return userAggregateID(e.UserID) }
+
+// AddressDeleted is emitted after an address has been removed
+type AddressDeleted struct {
+	UserID    uint `json:"user_id"`
+	AddressID uint `json:"address_id"`
+}
+
+func (e AddressDeleted) EventType() string   { return TypeAddressDeleted }
+func (e AddressDeleted) AggregateID() string { return userAggregateID(e.UserID) }
+
+// AddressMerged is emitted after duplicate addresses have been merged into one address.
+// The merged addresses are deleted, references to them should be moved to Address.
+type AddressMerged struct {
+	UserID           uint    `json:"user_id"`
+	Address          Address `json:"address"`
+	MergedAddressIDs []uint  `json:"merged_address_ids"`
+}
+
+func (e AddressMerged) EventType() string   { return TypeAddressMerged }
+func (e AddressMerged) AggregateID() string { return userAggregateID(e.UserID) }
+
+// NewDeviceLogin is emitted after a successful login from a device or country the user has not logged in from before.
+// Notification services use it to alert the user.
+type NewDeviceLogin struct {
+	UserID     uint      `json:"user_id"`
+	Email      string    `json:"email"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	Country    string    `json:"country,omitempty"`
+	City       string    `json:"city,omitempty"`
+	NewDevice  bool      `json:"new_device"`
+	NewCountry bool      `json:"new_country"`
+	LoggedInAt time.Time `json:"logged_in_at"`
+}
+
+func (e NewDeviceLogin) EventType() string   { return TypeNewDeviceLogin }
+func (e NewDeviceLogin) AggregateID() string { return userAggregateID(e.UserID) }
+
+func userAggregateID(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher produces events to a Kafka topic over the Kafka protocol.
+// Records are keyed by aggregate ID and partitioned with murmur2 like the Java client,
+// so all events of a user land on the same partition in order.
+type KafkaPublisher struct {
+	writer  *kafka.Writer
+	encoder Encoder
+}
+
+// NewKafkaPublisher creates a publisher for the cluster reachable through brokers
+func NewKafkaPublisher(brokers []string, topic string, encoder Encoder) (*KafkaPublisher, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("KAFKA_BROKERS is required for the kafka event publisher")
+	}
+	if topic == "" {
+		return nil, errors.New("KAFKA_TOPIC is required for the kafka event publisher")
+	}
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(brokers...),
+			Topic:    topic,
+			Balancer: &kafka.Murmur2Balancer{},
+			// The relay publishes one event at a time and waits for it, so there is nothing to batch
+			BatchSize:    1,
+			BatchTimeout: time.Millisecond,
+			RequiredAcks: kafka.RequireAll,
+			MaxAttempts:  3,
+		},
+		encoder: encoder,
+	}, nil
+}
+
+// Publish produces the envelope and waits until all in-sync replicas have acknowledged it
+func (p *KafkaPublisher) Publish(ctx context.Context, envelope *Envelope) error {
+	payload, err := p.encoder.Encode(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(envelope.AggregateID),
+		Value: payload,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte(p.encoder.ContentType())},
+			{Key: "event-id", Value: []byte(envelope.ID)},
+			{Key: "event-type", Value: []byte(envelope.Type)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to produce event to topic %s: %w", p.writer.Topic, err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the broker connections
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,110 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// KafkaRESTPublisher produces events to a Kafka topic through the Kafka REST Proxy v2 API,
+// which is served by Confluent REST Proxy and the Redpanda HTTP Proxy. It is an alternative to
+// KafkaPublisher for deployments where the service can only reach Kafka over HTTP.
+// Records are keyed by aggregate ID so that all events of a user land on the same partition in order.
+type KafkaRESTPublisher struct {
+	endpoint string
+	topic    string
+	encoder  Encoder
+	client   *http.Client
+}
+
+// NewKafkaRESTPublisher creates a publisher for the REST proxy at restURL
+func NewKafkaRESTPublisher(restURL, topic string, encoder Encoder) (*KafkaRESTPublisher, error) {
+	if restURL == "" {
+		return nil, errors.New("KAFKA_REST_URL is required for the kafka-rest event publisher")
+	}
+	if topic == "" {
+		return nil, errors.New("KAFKA_TOPIC is required for the kafka-rest event publisher")
+	}
+	return &KafkaRESTPublisher{
+		endpoint: strings.TrimRight(restURL, "/") + "/topics/" + topic,
+		topic:    topic,
+		encoder:  encoder,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition *int32 `json:"partition"`
+		Offset    *int64 `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Publish produces the envelope as a binary record and checks the per-record result
+func (p *KafkaRESTPublisher) Publish(ctx context.Context, envelope *Envelope) error {
+	payload, err := p.encoder.Encode(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	body, err := json.Marshal(kafkaProduceRequest{Records: []kafkaRecord{{
+		Key:   base64.StdEncoding.EncodeToString([]byte(envelope.AggregateID)),
+		Value: base64.StdEncoding.EncodeToString(payload),
+	}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.binary.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to produce event to topic %s: %w", p.topic, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kafka REST proxy returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result kafkaProduceResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("failed to decode kafka REST proxy response: %w", err)
+	}
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != nil || offset.Error != "" {
+			return fmt.Errorf("kafka rejected event %s: %s", envelope.ID, offset.Error)
+		}
+	}
+	return nil
+}
+
+// Close releases idle HTTP connections
+func (p *KafkaRESTPublisher) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
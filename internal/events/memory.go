@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// MemoryPublisher keeps published envelopes in memory, intended for tests and local development
+type MemoryPublisher struct {
+	mu        sync.Mutex
+	envelopes []*Envelope
+	failWith  error
+	closed    bool
+}
+
+// NewMemoryPublisher creates an empty MemoryPublisher
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish records the envelope, or returns the error set by FailWith
+func (p *MemoryPublisher) Publish(_ context.Context, envelope *Envelope) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return errors.New("publisher is closed")
+	}
+	if p.failWith != nil {
+		return p.failWith
+	}
+	p.envelopes = append(p.envelopes, envelope)
+	return nil
+}
+
+// Close marks the publisher as closed, later publishes fail
+func (p *MemoryPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+// Published returns a copy of all envelopes published so far
+func (p *MemoryPublisher) Published() []*Envelope {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*Envelope(nil), p.envelopes...)
+}
+
+// FailWith makes subsequent publishes return err, nil restores normal behavior
+func (p *MemoryPublisher) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failWith = err
+}
+
+// Reset discards all recorded envelopes
+func (p *MemoryPublisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.envelopes = nil
+}
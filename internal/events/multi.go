@@ -0,0 +1,37 @@
+package events
+
+import (
+	"context"
+	"errors"
+)
+
+// MultiPublisher fans every envelope out to several publishers.
+// Publish fails if any publisher fails, so the outbox relay retries the event for all of them;
+// each publisher therefore has to tolerate receiving the same envelope more than once.
+// A failing publisher does not hold the envelope back from the others.
+type MultiPublisher struct {
+	publishers []EventPublisher
+}
+
+// NewMultiPublisher creates a MultiPublisher, publishers are called in the given order
+func NewMultiPublisher(publishers ...EventPublisher) *MultiPublisher {
+	return &MultiPublisher{publishers: publishers}
+}
+
+// Publish hands the envelope to every publisher and returns their combined errors
+func (p *MultiPublisher) Publish(ctx context.Context, envelope *Envelope) error {
+	var errs []error
+	for _, publisher := range p.publishers {
+		errs = append(errs, publisher.Publish(ctx, envelope))
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes all publishers and returns their combined errors
+func (p *MultiPublisher) Close() error {
+	var errs []error
+	for _, publisher := range p.publishers {
+		errs = append(errs, publisher.Close())
+	}
+	return errors.Join(errs...)
+}
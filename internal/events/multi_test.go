@@ -0,0 +1,66 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMultiPublisher(t *testing.T) {
+	errFirst := errors.New("first is down")
+	errSecond := errors.New("second is down")
+
+	tests := []struct {
+		name      string
+		failWith  []error
+		wantErrs  []error
+		published []int // envelopes published by each publisher
+	}{
+		{"all succeed", []error{nil, nil, nil}, nil, []int{1, 1, 1}},
+		{"one fails", []error{errFirst, nil, nil}, []error{errFirst}, []int{0, 1, 1}},
+		{"several fail", []error{errFirst, nil, errSecond}, []error{errFirst, errSecond}, []int{0, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var publishers []EventPublisher
+			var memories []*MemoryPublisher
+			for _, err := range tt.failWith {
+				p := NewMemoryPublisher()
+				p.FailWith(err)
+				publishers = append(publishers, p)
+				memories = append(memories, p)
+			}
+
+			err := NewMultiPublisher(publishers...).Publish(context.Background(), &Envelope{ID: "1"})
+			if len(tt.wantErrs) == 0 && err != nil {
+				t.Fatalf("Publish() = %v, want nil", err)
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("Publish() = %v, want it to include %v", err, want)
+				}
+			}
+			for i, p := range memories {
+				if got := len(p.Published()); got != tt.published[i] {
+					t.Errorf("publisher %d got %d envelopes, want %d", i, got, tt.published[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMultiPublisherClose(t *testing.T) {
+	first, second := NewMemoryPublisher(), NewMemoryPublisher()
+	multi := NewMultiPublisher(first, second)
+	if err := multi.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// MemoryPublisher rejects publishes once closed, so both errors must be reported
+	err := multi.Publish(context.Background(), &Envelope{ID: "1"})
+	if err == nil {
+		t.Fatal("Publish after Close succeeded")
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 2 {
+		t.Errorf("Publish after Close returned %d errors, want 2", n)
+	}
+}
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher publishes events to NATS subjects named <prefix>.<event type>.
+// With JetStream enabled publishes are acknowledged by the stream and deduplicated by event ID.
+type NATSPublisher struct {
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+	prefix  string
+	encoder Encoder
+}
+
+// NewNATSPublisher connects to the NATS server at url
+func NewNATSPublisher(url, subjectPrefix string, jetStream bool, encoder Encoder) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name(Source), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	p := &NATSPublisher{conn: conn, prefix: subjectPrefix, encoder: encoder}
+	if jetStream {
+		if p.js, err = conn.JetStream(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// Publish sends the envelope, it waits for the stream acknowledgement when JetStream is enabled
+func (p *NATSPublisher) Publish(ctx context.Context, envelope *Envelope) error {
+	payload, err := p.encoder.Encode(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	msg := nats.NewMsg(p.subject(envelope.Type))
+	msg.Data = payload
+	msg.Header.Set(nats.MsgIdHdr, envelope.ID)
+	msg.Header.Set("Content-Type", p.encoder.ContentType())
+	msg.Header.Set("Event-Type", envelope.Type)
+	msg.Header.Set("Aggregate-Id", envelope.AggregateID)
+
+	if p.js != nil {
+		if _, err := p.js.PublishMsg(msg, nats.Context(ctx)); err != nil {
+			return fmt.Errorf("failed to publish event to JetStream: %w", err)
+		}
+		return nil
+	}
+
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("failed to publish event to NATS: %w", err)
+	}
+	// Core NATS is fire-and-forget, flushing at least surfaces connection errors to the relay
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush NATS connection: %w", err)
+	}
+	return nil
+}
+
+// Close drains pending messages and closes the connection
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
+
+func (p *NATSPublisher) subject(eventType string) string {
+	if p.prefix == "" {
+		return eventType
+	}
+	return p.prefix + "." + eventType
+}
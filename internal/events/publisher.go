@@ -0,0 +1,70 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blackwatch66/user-microservice/config"
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/go-redis/redis/v8"
+)
+
+var log = logger.For("events")
+
+// Supported values for EVENT_PUBLISHER
+const (
+	PublisherLog       = "log"
+	PublisherKafka     = "kafka"
+	PublisherKafkaREST = "kafka-rest"
+	PublisherNATS      = "nats"
+	PublisherRedis     = "redis"
+	PublisherMemory    = "memory"
+)
+
+// EventPublisher delivers event envelopes to a message broker.
+// Publish must only return nil once the broker has durably accepted the event,
+// the outbox relay retries on error which gives at-least-once delivery.
+type EventPublisher interface {
+	Publish(ctx context.Context, envelope *Envelope) error
+	Close() error
+}
+
+// NewPublisher creates the EventPublisher selected by cfg.EventPublisher.
+// The Redis Streams backend reuses rdb instead of opening a new connection.
+func NewPublisher(cfg *config.Config, rdb redis.UniversalClient) (EventPublisher, error) {
+	encoder, err := NewEncoder(cfg.EventEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.EventPublisher {
+	case PublisherLog, "":
+		return LogPublisher{}, nil
+	case PublisherMemory:
+		return NewMemoryPublisher(), nil
+	case PublisherKafka:
+		return NewKafkaPublisher(cfg.KafkaBrokers, cfg.KafkaTopic, encoder)
+	case PublisherKafkaREST:
+		return NewKafkaRESTPublisher(cfg.KafkaRESTURL, cfg.KafkaTopic, encoder)
+	case PublisherNATS:
+		return NewNATSPublisher(cfg.NATSURL, cfg.NATSSubjectPrefix, cfg.NATSJetStream, encoder)
+	case PublisherRedis:
+		return NewRedisStreamPublisher(rdb, cfg.RedisStreamKey, cfg.RedisStreamMaxLen, encoder), nil
+	default:
+		return nil, fmt.Errorf("unsupported event publisher: %s", cfg.EventPublisher)
+	}
+}
+
+// LogPublisher only logs events, it is used when no message broker is configured
+type LogPublisher struct{}
+
+// Publish logs the event
+func (LogPublisher) Publish(ctx context.Context, envelope *Envelope) error {
+	log.InfoContext(ctx, "Event published", "event_id", envelope.ID, "event_type", envelope.Type, "aggregate_id", envelope.AggregateID)
+	return nil
+}
+
+// Close is a no-op
+func (LogPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStreamPublisher appends events to a Redis stream with XADD
+type RedisStreamPublisher struct {
+	rdb     redis.UniversalClient
+	stream  string
+	maxLen  int64
+	encoder Encoder
+}
+
+// NewRedisStreamPublisher creates a publisher writing to the given stream.
+// When maxLen is positive the stream is trimmed to roughly that many entries.
+func NewRedisStreamPublisher(rdb redis.UniversalClient, stream string, maxLen int64, encoder Encoder) *RedisStreamPublisher {
+	return &RedisStreamPublisher{rdb: rdb, stream: stream, maxLen: maxLen, encoder: encoder}
+}
+
+// Publish adds the envelope to the stream, routing fields are stored next to the encoded payload
+func (p *RedisStreamPublisher) Publish(ctx context.Context, envelope *Envelope) error {
+	payload, err := p.encoder.Encode(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	args := &redis.XAddArgs{
+		Stream: p.stream,
+		Values: map[string]interface{}{
+			"id":           envelope.ID,
+			"type":         envelope.Type,
+			"aggregate_id": envelope.AggregateID,
+			"content_type": p.encoder.ContentType(),
+			"payload":      payload,
+		},
+	}
+	if p.maxLen > 0 {
+		args.MaxLen = p.maxLen
+		args.Approx = true
+	}
+	if err := p.rdb.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to add event to stream %s: %w", p.stream, err)
+	}
+	return nil
+}
+
+// Close is a no-op, the Redis client is shared with the rest of the service
+func (p *RedisStreamPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,77 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/oschwald/geoip2-golang"
+)
+
+var log = logger.For("geoip")
+
+// Location is the coarse location of an IP address, empty fields are unknown
+type Location struct {
+	Country string // ISO 3166-1 alpha-2 code
+	City    string // English city name, only available with a City database
+}
+
+// Locator resolves IP addresses to locations
+type Locator interface {
+	Lookup(ip string) Location
+	Close() error
+}
+
+// Open loads a MaxMind DB file (GeoLite2/GeoIP2 City or Country).
+// An empty path returns a Locator that never resolves anything.
+func Open(path string) (Locator, error) {
+	if path == "" {
+		return noopLocator{}, nil
+	}
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	log.Info("GeoIP database loaded", "path", path, "type", reader.Metadata().DatabaseType)
+	return &maxmindLocator{reader: reader}, nil
+}
+
+type maxmindLocator struct {
+	reader *geoip2.Reader
+}
+
+// Lookup returns the location of ip, private and invalid addresses resolve to an empty Location
+func (l *maxmindLocator) Lookup(ip string) Location {
+	addr := net.ParseIP(ip)
+	if addr == nil || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() {
+		return Location{}
+	}
+
+	city, err := l.reader.City(addr)
+	if err == nil {
+		return Location{Country: city.Country.IsoCode, City: city.City.Names["en"]}
+	}
+	var invalidMethod geoip2.InvalidMethodError
+	if !errors.As(err, &invalidMethod) {
+		log.Warn("GeoIP lookup failed", "error", err)
+		return Location{}
+	}
+
+	// Country databases do not support City lookups
+	country, err := l.reader.Country(addr)
+	if err != nil {
+		log.Warn("GeoIP lookup failed", "error", err)
+		return Location{}
+	}
+	return Location{Country: country.Country.IsoCode}
+}
+
+func (l *maxmindLocator) Close() error {
+	return l.reader.Close()
+}
+
+type noopLocator struct{}
+
+func (noopLocator) Lookup(string) Location { return Location{} }
+func (noopLocator) Close() error           { return nil }
@@ -0,0 +1,131 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckFunc probes a single dependency and returns an error if it is unhealthy
+type CheckFunc func(ctx context.Context) error
+
+// Result holds the outcome of a single dependency check
+type Result struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+	// Optional dependencies degrade the service when they fail instead of making it unready
+	Optional bool
+	// Degraded describes how dependent features behave while an optional dependency fails
+	Degraded map[string]string
+}
+
+// check is a registered dependency check
+type check struct {
+	fn       CheckFunc
+	optional bool
+	degraded map[string]string
+}
+
+// Checker runs a set of named dependency checks with a per-check timeout
+type Checker struct {
+	mu      sync.RWMutex
+	names   []string
+	checks  map[string]check
+	timeout time.Duration
+
+	shuttingDown atomic.Bool
+}
+
+// NewChecker creates a new Checker, each check is bounded by timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		checks:  make(map[string]check),
+		timeout: timeout,
+	}
+}
+
+// Register adds a named dependency check, re-registering a name replaces the previous check
+func (c *Checker) Register(name string, fn CheckFunc) {
+	c.register(name, check{fn: fn})
+}
+
+// RegisterOptional adds a check of a dependency the service can run without.
+// degraded maps each feature depending on it to how that feature behaves while the check fails.
+func (c *Checker) RegisterOptional(name string, fn CheckFunc, degraded map[string]string) {
+	c.register(name, check{fn: fn, optional: true, degraded: degraded})
+}
+
+func (c *Checker) register(name string, check check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.checks[name]; !exists {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Run executes all registered checks concurrently and returns results in registration order
+func (c *Checker) Run(ctx context.Context) []Result {
+	c.mu.RLock()
+	names := append([]string(nil), c.names...)
+	checks := make([]check, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.mu.RUnlock()
+
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := checks[i].fn(checkCtx)
+			results[i] = Result{
+				Name:     names[i],
+				Err:      err,
+				Duration: time.Since(start),
+				Optional: checks[i].optional,
+				Degraded: checks[i].degraded,
+			}
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// MarkShuttingDown flags the process as draining, readiness fails from this point on
+func (c *Checker) MarkShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether MarkShuttingDown has been called
+func (c *Checker) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// AllHealthy reports whether every required dependency in the list succeeded
+func AllHealthy(results []Result) bool {
+	for _, r := range results {
+		if r.Err != nil && !r.Optional {
+			return false
+		}
+	}
+	return true
+}
+
+// Degraded reports whether an optional dependency in the list failed
+func Degraded(results []Result) bool {
+	for _, r := range results {
+		if r.Err != nil && r.Optional {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"github.com/blackwatch66/user-microservice/internal/repository"
+)
+
+var log = logger.For("idempotency")
+
+// MaxKeyLength is the longest accepted idempotency key
+const MaxKeyLength = 255
+
+// reserveTTL bounds how long a key stays reserved by a request that never completes,
+// e.g. because the instance handling it crashed
+const reserveTTL = time.Minute
+
+var (
+	// ErrInvalidKey is returned for keys that are empty, too long or contain non-printable characters
+	ErrInvalidKey = errors.New("invalid idempotency key")
+	// ErrMismatch is returned when a key is reused for a request with a different payload
+	ErrMismatch = errors.New("idempotency key was already used for a different request")
+	// ErrInProgress is returned while the first request made with a key has not completed
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+	// ErrUnavailable is returned when the store fails and the guard is configured to fail closed
+	ErrUnavailable = errors.New("idempotency store is unavailable")
+)
+
+// Guard makes retried requests carrying the same idempotency key return the response of the first one.
+// Keys are scoped by the caller, so the same key sent by two users never collides.
+type Guard struct {
+	store      repository.IdempotencyStore
+	ttl        time.Duration
+	failClosed bool
+}
+
+// NewGuard creates a Guard that keeps responses for ttl.
+// When the store fails, requests are processed without idempotency unless failClosed is set.
+func NewGuard(store repository.IdempotencyStore, ttl time.Duration, failClosed bool) *Guard {
+	return &Guard{store: store, ttl: ttl, failClosed: failClosed}
+}
+
+// Fingerprint hashes the parts that identify a request, typically method, path and body
+func Fingerprint(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		// Length prefixes keep ("ab", "c") and ("a", "bc") apart
+		fmt.Fprintf(h, "%d:", len(part))
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ValidKey reports whether key can be used as an idempotency key
+func ValidKey(key string) bool {
+	if key == "" || len(key) > MaxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Attempt is a request being processed under an idempotency key
+type Attempt struct {
+	guard       *Guard
+	key         string
+	fingerprint string
+	reserved    bool
+	// Replay is the stored response of the first request, the request must not be processed again when set
+	Replay *repository.IdempotencyRecord
+}
+
+// Begin reserves key for the request identified by fingerprint.
+// It returns ErrMismatch or ErrInProgress when the key is taken by another request, and an
+// Attempt whose Replay is set when the key belongs to an identical request that has completed.
+// Otherwise the request must be processed and the Attempt finished with Complete or Release.
+func (g *Guard) Begin(ctx context.Context, scope, key, fingerprint string) (*Attempt, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+	attempt := &Attempt{guard: g, key: scope + ":" + key, fingerprint: fingerprint}
+	existing, err := g.store.Reserve(ctx, attempt.key, fingerprint, reserveTTL)
+	if err != nil {
+		if g.failClosed {
+			observe("unavailable")
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		observe("bypassed")
+		log.WarnContext(ctx, "Idempotency store failed, processing request without idempotency", "error", err)
+		return attempt, nil
+	}
+	switch {
+	case existing == nil:
+		observe("processed")
+		attempt.reserved = true
+		return attempt, nil
+	case existing.Fingerprint != fingerprint:
+		observe("mismatch")
+		return nil, ErrMismatch
+	case !existing.Completed:
+		observe("in_progress")
+		return nil, ErrInProgress
+	default:
+		observe("replayed")
+		attempt.Replay = existing
+		return attempt, nil
+	}
+}
+
+func observe(result string) {
+	metrics.IdempotencyRequestsTotal.WithLabelValues(result).Inc()
+}
+
+// Complete stores the response of the request so that retries replay it
+func (a *Attempt) Complete(ctx context.Context, record *repository.IdempotencyRecord) {
+	if !a.reserved {
+		return
+	}
+	a.reserved = false
+	record.Fingerprint = a.fingerprint
+	record.Completed = true
+	if err := a.guard.store.Complete(ctx, a.key, record, a.guard.ttl); err != nil {
+		log.WarnContext(ctx, "Failed to store idempotent response, a retry will be processed again", "error", err)
+		a.release(ctx)
+	}
+}
+
+// Release gives up the key without storing a response, a retry is processed again.
+// It is used for failures that are worth retrying, such as internal errors.
+func (a *Attempt) Release(ctx context.Context) {
+	if !a.reserved {
+		return
+	}
+	a.reserved = false
+	a.release(ctx)
+}
+
+func (a *Attempt) release(ctx context.Context) {
+	if err := a.guard.store.Release(ctx, a.key); err != nil {
+		log.WarnContext(ctx, "Failed to release idempotency key, retries are rejected until it expires", "error", err, "expires_in", reserveTTL.String())
+	}
+}
@@ -0,0 +1,105 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/repository"
+)
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	first, other := Fingerprint([]byte("POST"), []byte(`{"a":1}`)), Fingerprint([]byte("POST"), []byte(`{"a":2}`))
+	response := &repository.IdempotencyRecord{Status: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+
+	tests := []struct {
+		name        string
+		complete    bool // the first request made with the key stored its response
+		release     bool // the first request gave up the key
+		scope       string
+		fingerprint string
+		wantErr     error
+		wantReplay  bool
+	}{
+		{"identical retry replays", true, false, "user:1", first, nil, true},
+		{"different body", true, false, "user:1", other, ErrMismatch, false},
+		{"retry while in progress", false, false, "user:1", first, ErrInProgress, false},
+		{"different body while in progress", false, false, "user:1", other, ErrMismatch, false},
+		{"retry after release is processed again", false, true, "user:1", first, nil, false},
+		{"same key in another scope", true, false, "user:2", other, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := NewGuard(repository.NewMemoryIdempotencyStore(), time.Hour, false)
+			attempt, err := guard.Begin(ctx, "user:1", "key-1", first)
+			if err != nil || attempt.Replay != nil {
+				t.Fatalf("first Begin() = %+v, %v, want a new attempt", attempt, err)
+			}
+			switch {
+			case tt.complete:
+				attempt.Complete(ctx, response)
+			case tt.release:
+				attempt.Release(ctx)
+			}
+
+			retry, err := guard.Begin(ctx, tt.scope, "key-1", tt.fingerprint)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("retry Begin() = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := retry.Replay != nil; got != tt.wantReplay {
+				t.Fatalf("retry replayed = %v, want %v", got, tt.wantReplay)
+			}
+			if tt.wantReplay && (retry.Replay.Status != response.Status || string(retry.Replay.Body) != string(response.Body)) {
+				t.Errorf("replayed %+v, want %+v", retry.Replay, response)
+			}
+		})
+	}
+}
+
+func TestGuardInvalidKey(t *testing.T) {
+	guard := NewGuard(repository.NewMemoryIdempotencyStore(), time.Hour, false)
+	for _, key := range []string{"", "has space", string(make([]byte, MaxKeyLength+1))} {
+		if _, err := guard.Begin(context.Background(), "user:1", key, "fp"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Begin(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+// failingStore is an IdempotencyStore that cannot be reached
+type failingStore struct {
+	repository.IdempotencyStore
+}
+
+func (failingStore) Reserve(context.Context, string, string, time.Duration) (*repository.IdempotencyRecord, error) {
+	return nil, errors.New("redis is unavailable")
+}
+
+func TestGuardStoreFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		failClosed bool
+		wantErr    error
+	}{
+		{"fail open processes the request", false, nil},
+		{"fail closed rejects the request", true, ErrUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := NewGuard(failingStore{}, time.Hour, tt.failClosed)
+			attempt, err := guard.Begin(context.Background(), "user:1", "key-1", "fp")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Begin() = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				// Nothing was reserved, so finishing the attempt must not touch the store
+				attempt.Complete(context.Background(), &repository.IdempotencyRecord{Status: 200})
+				attempt.Release(context.Background())
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultComponent is the component used by slog.Default() and the standard log package
+const DefaultComponent = "app"
+
+var (
+	mu              sync.RWMutex
+	baseHandler     slog.Handler = newBaseHandler(os.Stderr, "json")
+	defaultLevel                 = slog.LevelInfo
+	componentLevels              = map[string]slog.Level{}
+)
+
+// Init configures the process-wide structured logger.
+// level is the default minimum level, componentSpec overrides it per component ("gorm=warn,http=debug"),
+// format is either "json" or "text". slog.Default() and the standard log package are redirected as well.
+func Init(w io.Writer, level, componentSpec, format string) error {
+	def, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	overrides, err := parseComponentLevels(componentSpec)
+	if err != nil {
+		return err
+	}
+	if format != "json" && format != "text" {
+		return fmt.Errorf("unsupported log format: %s", format)
+	}
+
+	mu.Lock()
+	baseHandler = newBaseHandler(w, format)
+	defaultLevel = def
+	componentLevels = overrides
+	mu.Unlock()
+
+	slog.SetDefault(For(DefaultComponent))
+	return nil
+}
+
+// For returns a logger tagged with the given component, filtered by that component's level.
+// The returned logger follows later calls to Init, so it is safe to keep in package-level variables.
+func For(component string) *slog.Logger {
+	return slog.New(&componentHandler{component: component})
+}
+
+// ParseLevel parses debug, info, warn or error (case-insensitive)
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: %w", s, err)
+	}
+	return level, nil
+}
+
+// parseComponentLevels parses "component=level" pairs separated by commas
+func parseComponentLevels(spec string) (map[string]slog.Level, error) {
+	levels := make(map[string]slog.Level)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		component, levelStr, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid component log level %q, expected component=level", pair)
+		}
+		level, err := ParseLevel(levelStr)
+		if err != nil {
+			return nil, err
+		}
+		levels[strings.TrimSpace(component)] = level
+	}
+	return levels, nil
+}
+
+// newBaseHandler builds the output handler: request/trace IDs are added from the context and
+// every attribute, including the message, passes through redaction
+func newBaseHandler(w io.Writer, format string) slog.Handler {
+	opts := &slog.HandlerOptions{
+		// Filtering is done per component in componentHandler
+		Level:       slog.LevelDebug,
+		ReplaceAttr: redactAttr,
+	}
+	if format == "text" {
+		return &contextHandler{Handler: slog.NewTextHandler(w, opts)}
+	}
+	return &contextHandler{Handler: slog.NewJSONHandler(w, opts)}
+}
+
+func levelFor(component string) slog.Level {
+	mu.RLock()
+	defer mu.RUnlock()
+	if level, ok := componentLevels[component]; ok {
+		return level
+	}
+	return defaultLevel
+}
+
+func currentBase() slog.Handler {
+	mu.RLock()
+	defer mu.RUnlock()
+	return baseHandler
+}
+
+// componentHandler resolves the base handler and level at log time so that
+// loggers created before Init pick up the final configuration
+type componentHandler struct {
+	component string
+	ops       []func(slog.Handler) slog.Handler
+}
+
+func (h *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= levelFor(h.component)
+}
+
+func (h *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	handler := currentBase().WithAttrs([]slog.Attr{slog.String("component", h.component)})
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *componentHandler) with(op func(slog.Handler) slog.Handler) slog.Handler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &componentHandler{component: h.component, ops: append(ops, op)}
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redacted replaces the value of sensitive attributes
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute key fragments whose values are never logged
+var sensitiveKeys = []string{"password", "token", "authorization", "secret", "cookie", "jwt"}
+
+var (
+	jwtPattern     = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearerPattern  = regexp.MustCompile(`(?i)bearer\s+[^\s"']+`)
+	bcryptPattern  = regexp.MustCompile(`\$2[abxy]?\$\d{2}\$[./A-Za-z0-9]{53}`)
+	webhookPattern = regexp.MustCompile(`whsec_[0-9A-Za-z]+`)
+	emailPattern   = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+)
+
+// redactAttr is used as slog.HandlerOptions.ReplaceAttr, it also sees the built-in message attribute
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, RedactString(a.Value.String()))
+	case slog.KindAny:
+		// Errors and other values are rendered as text, so scrub their text form
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, RedactString(err.Error()))
+		}
+	}
+	return a
+}
+
+// isSensitiveKey reports whether an attribute key names a secret
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactEmail masks an email address like RedactString, it also masks values that are not valid emails
+func RedactEmail(email string) string {
+	local, domain, found := strings.Cut(email, "@")
+	if local == "" {
+		return Redacted
+	}
+	_, size := utf8.DecodeRuneInString(local)
+	if !found {
+		return local[:size] + "***"
+	}
+	return local[:size] + "***@" + domain
+}
+
+// RedactString masks tokens, password hashes, webhook secrets and email addresses embedded in s.
+// Emails keep their first character and domain ("j***@example.com") so log lines remain useful.
+func RedactString(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+Redacted)
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bcryptPattern.ReplaceAllString(s, Redacted)
+	s = webhookPattern.ReplaceAllString(s, Redacted)
+	s = emailPattern.ReplaceAllString(s, "$1***@$2")
+	return s
+}
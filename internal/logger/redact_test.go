@@ -0,0 +1,39 @@
+package logger
+
+import "testing"
+
+func TestRedactEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"ada@example.com", "a***@example.com"},
+		{"émile@example.fr", "é***@example.fr"},
+		{"not-an-email", "n***"},
+		{"@example.com", Redacted},
+		{"", Redacted},
+	}
+	for _, tt := range tests {
+		if got := RedactEmail(tt.email); got != tt.want {
+			t.Errorf("RedactEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestRedactString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"login failed for ada@example.com", "login failed for a***@example.com"},
+		{"Authorization: Bearer abc.def", "Authorization: Bearer " + Redacted},
+		{"token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOjF9.c2ln", "token " + Redacted},
+		{"signing with whsec_3f9a0c1e", "signing with " + Redacted},
+		{"nothing to hide", "nothing to hide"},
+	}
+	for _, tt := range tests {
+		if got := RedactString(tt.in); got != tt.want {
+			t.Errorf("RedactString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/blackwatch66/user-microservice/internal/auth"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "user_service"
+
+// Registry holds every collector exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestsTotal counts HTTP requests per gin route and status code
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes HTTP request latency per gin route
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// GRPCRequestsTotal counts gRPC calls per method and status code
+	GRPCRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "Total number of gRPC requests by full method name and status code.",
+	}, []string{"method", "code"})
+
+	// GRPCRequestDuration observes gRPC call latency per method
+	GRPCRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC request latency by full method name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	// LoginAttemptsTotal counts login attempts by outcome
+	LoginAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_login_attempts_total",
+		Help:      "Total number of login attempts by result (success, invalid_credentials, error).",
+	}, []string{"result"})
+
+	// TokenValidationsTotal counts JWT validations by transport and outcome
+	TokenValidationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_token_validations_total",
+		Help:      "Total number of token validations by transport (http, grpc) and result.",
+	}, []string{"transport", "result"})
+
+	// OutboxEventsTotal counts outbox publish attempts by event type and result
+	OutboxEventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_events_total",
+		Help:      "Total number of outbox publish attempts by event type and result (published, error).",
+	}, []string{"event_type", "result"})
+
+	// WebhookDeliveriesTotal counts webhook delivery attempts by result
+	WebhookDeliveriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Total number of webhook delivery attempts by result (succeeded, failed, dead).",
+	}, []string{"result"})
+
+	// DBReplicaUp reports whether each read replica is currently receiving reads
+	DBReplicaUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_replica_up",
+		Help:      "Whether the read replica passed its last health check (1) or is ejected (0).",
+	}, []string{"replica"})
+
+	// DBReplicaReadsTotal counts replica-eligible reads by the pool that served them
+	DBReplicaReadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_replica_reads_total",
+		Help:      "Total number of replica-eligible reads by target (replica, primary when no replica is healthy or the replica failed).",
+	}, []string{"target"})
+
+	// CacheRequestsTotal counts read-through cache lookups by cache and result
+	CacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Total number of cache lookups by cache (profile, addresses) and result (hit, miss, error).",
+	}, []string{"cache", "result"})
+
+	// IdempotencyRequestsTotal counts requests carrying an idempotency key by outcome
+	IdempotencyRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "idempotency_requests_total",
+		Help:      "Total number of requests with an idempotency key by result (processed, replayed, mismatch, in_progress, unavailable, bypassed).",
+	}, []string{"result"})
+
+	// RedisCircuitOpen reports whether the Redis circuit breaker currently rejects commands
+	RedisCircuitOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "redis_circuit_open",
+		Help:      "Whether Redis is considered unreachable and commands fail fast (1) or are sent to Redis (0).",
+	})
+
+	// RedisCommandDuration observes Redis command latency
+	RedisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Redis command latency by command name and result (ok, nil, error).",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command", "result"})
+)
+
+// Cache lookup results
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+// Login attempt results
+const (
+	LoginSuccess            = "success"
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginError              = "error"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		GRPCRequestsTotal,
+		GRPCRequestDuration,
+		LoginAttemptsTotal,
+		TokenValidationsTotal,
+		RedisCommandDuration,
+		RedisCircuitOpen,
+		OutboxEventsTotal,
+		WebhookDeliveriesTotal,
+		DBReplicaUp,
+		DBReplicaReadsTotal,
+		CacheRequestsTotal,
+		IdempotencyRequestsTotal,
+	)
+}
+
+// Handler returns the HTTP handler serving the metrics registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDBStats exposes connection pool statistics of db under the given name
+func RegisterDBStats(db *sql.DB, dbName string) error {
+	if err := Registry.Register(collectors.NewDBStatsCollector(db, dbName)); err != nil {
+		return fmt.Errorf("failed to register database stats collector: %w", err)
+	}
+	return nil
+}
+
+// ObserveLogin records the outcome of a login attempt
+func ObserveLogin(result string) {
+	LoginAttemptsTotal.WithLabelValues(result).Inc()
+}
+
+// ObserveTokenValidation records the outcome of a token validation for the given transport
+func ObserveTokenValidation(transport string, err error) {
+	TokenValidationsTotal.WithLabelValues(transport, tokenValidationResult(err)).Inc()
+}
+
+// tokenValidationResult maps a token validation error to a low-cardinality label value
+func tokenValidationResult(err error) string {
+	switch {
+	case err == nil:
+		return "valid"
+	case errors.Is(err, auth.ErrTokenExpired):
+		return "expired"
+	case errors.Is(err, auth.ErrTokenMalformed):
+		return "malformed"
+	case errors.Is(err, auth.ErrTokenNotValidYet):
+		return "not_yet_valid"
+	case errors.Is(err, auth.ErrTokenRevoked):
+		return "revoked"
+	default:
+		return "invalid"
+	}
+}
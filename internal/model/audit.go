@@ -0,0 +1,38 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditLogImmutable is returned when code tries to modify or delete an audit log entry
+var ErrAuditLogImmutable = errors.New("audit log entries are append-only")
+
+// AuditLog is an append-only record of a security-relevant action.
+// The hooks below stop GORM from changing entries, database triggers reject any other UPDATE and DELETE.
+type AuditLog struct {
+	ID           uint64    `gorm:"primaryKey;autoIncrement"`
+	OccurredAt   time.Time `gorm:"index;not null"`
+	Action       string    `gorm:"type:varchar(64);index;not null"`
+	Outcome      string    `gorm:"type:varchar(16);not null"`
+	ActorID      *uint     `gorm:"index"`                                             // authenticated user that performed the action, nil for anonymous requests
+	ActorType    string    `gorm:"type:varchar(16);index;not null;default:anonymous"` // user, system or anonymous
+	TargetUserID *uint     `gorm:"index"`                                             // user affected by the action, nil when unknown (e.g. login with an unknown email)
+	IP           string    `gorm:"type:varchar(45)"`
+	UserAgent    string    `gorm:"type:varchar(512)"`
+	RequestID    string    `gorm:"type:varchar(128);index"`
+	Changes      string    `gorm:"type:text"` // JSON object of field => {"old", "new"}
+	Details      string    `gorm:"type:text"` // JSON object with action specific context
+}
+
+// BeforeUpdate rejects updates of audit log entries
+func (AuditLog) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete rejects deletion of audit log entries
+func (AuditLog) BeforeDelete(*gorm.DB) error {
+	return ErrAuditLogImmutable
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// LoginHistory records a login attempt.
+// Attempts for an email without account have UserID 0, Email keeps the attempted email in redacted form.
+type LoginHistory struct {
+	ID            uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        uint      `gorm:"index:idx_login_history_user;not null" json:"-"`
+	Email         string    `gorm:"type:varchar(255)" json:"-"` // redacted, e.g. "j***@example.com"
+	Success       bool      `gorm:"not null" json:"success"`
+	FailureReason string    `gorm:"type:varchar(50)" json:"failure_reason,omitempty"`
+	IP            string    `gorm:"type:varchar(45)" json:"ip"`
+	UserAgent     string    `gorm:"type:varchar(512)" json:"user_agent"`
+	DeviceID      string    `gorm:"type:varchar(64);index" json:"device_id"` // hash of the user agent
+	Country       string    `gorm:"type:varchar(2)" json:"country,omitempty"`
+	City          string    `gorm:"type:varchar(100)" json:"city,omitempty"`
+	NewDevice     bool      `gorm:"not null;default:false" json:"new_device"`
+	NewCountry    bool      `gorm:"not null;default:false" json:"new_country"`
+	CreatedAt     time.Time `gorm:"index:idx_login_history_user" json:"created_at"`
+}
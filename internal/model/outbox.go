@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the change that caused it.
+// The outbox relay publishes pending rows and marks them as published.
+type OutboxEvent struct {
+	ID            uint64     `gorm:"primaryKey;autoIncrement"`
+	EventID       string     `gorm:"type:varchar(36);uniqueIndex;not null"`
+	EventType     string     `gorm:"type:varchar(100);not null"`
+	AggregateType string     `gorm:"type:varchar(50);not null"`
+	AggregateID   string     `gorm:"type:varchar(64);index;not null"`
+	Payload       string     `gorm:"type:text;not null"` // JSON encoded event payload
+	OccurredAt    time.Time  `gorm:"not null"`
+	PublishedAt   *time.Time `gorm:"index"`
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"index;not null"`
+	LastError     string     `gorm:"type:text"`
+	CreatedAt     time.Time
+}
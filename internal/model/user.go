@@ -14,8 +14,19 @@ type User struct {
 	FirstName    string `gorm:"type:varchar(50)"`
 	LastName     string `gorm:"type:varchar(50)"`
 	Addresses    []Address `gorm:"foreignKey:UserID"` // one-to-many relationship
+	Version      uint64 `gorm:"not null;default:1"` // incremented by every update, writes conditional on it detect concurrent edits
 }
 
+// Address types, each type has its own default address
+const (
+	AddressTypeShipping = "shipping"
+	AddressTypeBilling  = "billing"
+	AddressTypeOther    = "other"
+)
+
+// AddressTypes lists the valid address types
+var AddressTypes = []string{AddressTypeShipping, AddressTypeBilling, AddressTypeOther}
+
 // Address model
 type Address struct {
 	gorm.Model
@@ -25,7 +36,10 @@ type Address struct {
 	State       string `gorm:"type:varchar(100)"`
 	PostalCode  string `gorm:"type:varchar(20);not null"`
 	Country     string `gorm:"type:varchar(100);not null"`
-	IsDefault   bool   `gorm:"default:false"`
+	IsDefault   bool   `gorm:"default:false"` // exactly one address of each type is the default while the user has any
+	Type        string `gorm:"type:varchar(16);not null;default:shipping"` // one of the AddressType constants
+	Label       string `gorm:"type:varchar(50)"` // optional name chosen by the user, e.g. "Home"
+	Version     uint64 `gorm:"not null;default:1"` // incremented by every update, writes conditional on it detect concurrent edits
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 } 
\ No newline at end of file
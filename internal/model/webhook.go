@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+)
+
+// Webhook delivery states
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead" // retries exhausted, only redelivered manually
+)
+
+// WebhookSubscription is a partner endpoint that receives signed HTTP callbacks for matching events
+type WebhookSubscription struct {
+	ID          uint   `gorm:"primaryKey"`
+	URL         string `gorm:"type:varchar(2048);not null"`
+	EventTypes  string `gorm:"type:varchar(1024);not null"` // comma separated event type patterns, e.g. "user.registered,user.address_*"
+	Secret      string `gorm:"type:varchar(128);not null"`  // HMAC key, only returned when the subscription is created
+	Description string `gorm:"type:varchar(255)"`
+	Active      bool   `gorm:"not null;default:true"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// WebhookDelivery is one event to be delivered to one subscription
+type WebhookDelivery struct {
+	ID             uint64    `gorm:"primaryKey;autoIncrement"`
+	SubscriptionID uint      `gorm:"uniqueIndex:idx_webhook_delivery_event;not null"`
+	EventID        string    `gorm:"type:varchar(36);uniqueIndex:idx_webhook_delivery_event;not null"`
+	EventType      string    `gorm:"type:varchar(100);not null"`
+	Payload        string    `gorm:"type:text;not null"` // JSON encoded event envelope, sent as the request body
+	Status         string    `gorm:"type:varchar(20);index:idx_webhook_delivery_due;not null"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"index:idx_webhook_delivery_due;not null"`
+	LastStatusCode int       `gorm:"not null;default:0"`
+	LastError      string    `gorm:"type:text"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// WebhookAttempt records the outcome of a single delivery attempt
+type WebhookAttempt struct {
+	ID           uint64    `gorm:"primaryKey;autoIncrement"`
+	DeliveryID   uint64    `gorm:"index;not null"`
+	StatusCode   int       `gorm:"not null;default:0"` // 0 when no response was received
+	DurationMs   int64     `gorm:"not null"`
+	Error        string    `gorm:"type:text"`
+	ResponseBody string    `gorm:"type:text"` // truncated
+	CreatedAt    time.Time `gorm:"index"`
+}
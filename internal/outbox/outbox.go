@@ -0,0 +1,45 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NewEvent builds the outbox row for event
+func NewEvent(event events.Event) (*model.OutboxEvent, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", event.EventType(), err)
+	}
+
+	now := time.Now()
+	return &model.OutboxEvent{
+		EventID:       uuid.NewString(),
+		EventType:     event.EventType(),
+		AggregateType: events.AggregateUser,
+		AggregateID:   event.AggregateID(),
+		Payload:       string(payload),
+		OccurredAt:    now,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// Enqueue writes event to the outbox table using tx.
+// It must be called with the transaction that performs the corresponding change,
+// so that the event is stored if and only if the change is committed.
+func Enqueue(tx *gorm.DB, event events.Event) error {
+	row, err := NewEvent(event)
+	if err != nil {
+		return err
+	}
+	if err := tx.Create(row).Error; err != nil {
+		return fmt.Errorf("failed to write %s event to outbox: %w", event.EventType(), err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var log = logger.For("outbox")
+
+// RelayConfig controls polling, retries and retention of the relay
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxBackoff   time.Duration
+	Retention    time.Duration // published events older than this are purged, 0 keeps them forever
+}
+
+// Relay polls the outbox table and publishes pending events in insertion order.
+// Delivery is at-least-once: an event may be published again if the relay stops
+// after publishing but before marking it, so consumers must deduplicate by envelope ID.
+type Relay struct {
+	db        *gorm.DB
+	publisher events.EventPublisher
+	cfg       RelayConfig
+}
+
+// NewRelay creates a new Relay
+func NewRelay(db *gorm.DB, publisher events.EventPublisher, cfg RelayConfig) *Relay {
+	return &Relay{db: db, publisher: publisher, cfg: cfg}
+}
+
+// Run publishes pending events until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	lastPurge := time.Time{}
+	for {
+		// Drain the backlog before waiting for the next tick
+		for {
+			n, err := r.publishBatch(ctx)
+			if err != nil {
+				log.ErrorContext(ctx, "Outbox relay batch failed", "error", err)
+				break
+			}
+			if n < r.cfg.BatchSize {
+				break
+			}
+		}
+
+		if r.cfg.Retention > 0 && time.Since(lastPurge) > time.Hour {
+			r.purge(ctx)
+			lastPurge = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimLease hides claimed events from other relays while they are being published
+const claimLease = time.Minute
+
+// publishBatch claims up to BatchSize due events, publishes them in insertion order and records the outcome.
+// No transaction is open while talking to the broker: SQLite would otherwise block writes made by publishers
+// on other connections, such as webhook deliveries.
+// It stops at the first failure, so a broker outage costs one attempt per poll rather than one per event.
+// Ordering is best-effort: a failed event is retried after a backoff while later events may go out first.
+// It returns the number of events published.
+func (r *Relay) publishBatch(ctx context.Context) (int, error) {
+	batch, err := r.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range batch {
+		event := &batch[i]
+		if err := r.publisher.Publish(ctx, events.NewEnvelope(event)); err != nil {
+			metrics.OutboxEventsTotal.WithLabelValues(event.EventType, "error").Inc()
+			log.WarnContext(ctx, "Failed to publish event, will retry", "event_id", event.EventID, "event_type", event.EventType, "attempts", event.Attempts+1, "error", err)
+			if err := r.markFailed(ctx, event, err); err != nil {
+				return i, err
+			}
+			return i, r.release(ctx, batch[i+1:])
+		}
+
+		now := time.Now()
+		if err := r.db.WithContext(ctx).Model(event).Updates(map[string]interface{}{
+			"published_at": now,
+			"attempts":     event.Attempts + 1,
+			"last_error":   "",
+		}).Error; err != nil {
+			return i, fmt.Errorf("failed to mark event %s as published: %w", event.EventID, err)
+		}
+		metrics.OutboxEventsTotal.WithLabelValues(event.EventType, "published").Inc()
+	}
+	return len(batch), nil
+}
+
+// claim leases up to BatchSize due events by pushing their next attempt past claimLease.
+// Rows are locked with SKIP LOCKED so that several instances can run relays concurrently;
+// if the process dies mid-batch the lease expires and the events are published again.
+func (r *Relay) claim(ctx context.Context) ([]model.OutboxEvent, error) {
+	var batch []model.OutboxEvent
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("published_at IS NULL AND next_attempt_at <= ?", now).
+			Order("id").
+			Limit(r.cfg.BatchSize).
+			Find(&batch).Error; err != nil {
+			return fmt.Errorf("failed to load pending events: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		return tx.Model(&model.OutboxEvent{}).
+			Where("id IN ?", eventIDs(batch)).
+			Update("next_attempt_at", now.Add(claimLease)).Error
+	})
+	return batch, err
+}
+
+// release makes claimed but unpublished events due again
+func (r *Relay) release(ctx context.Context, batch []model.OutboxEvent) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Model(&model.OutboxEvent{}).
+		Where("id IN ?", eventIDs(batch)).
+		Update("next_attempt_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to release claimed events: %w", err)
+	}
+	return nil
+}
+
+// markFailed increments the attempt counter and schedules the next attempt with exponential backoff
+func (r *Relay) markFailed(ctx context.Context, event *model.OutboxEvent, publishErr error) error {
+	attempts := event.Attempts + 1
+	if err := r.db.WithContext(ctx).Model(event).Updates(map[string]interface{}{
+		"attempts":        attempts,
+		"last_error":      publishErr.Error(),
+		"next_attempt_at": time.Now().Add(Backoff(attempts, r.cfg.MaxBackoff)),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record publish failure for event %s: %w", event.EventID, err)
+	}
+	return nil
+}
+
+func eventIDs(batch []model.OutboxEvent) []uint64 {
+	ids := make([]uint64, 0, len(batch))
+	for _, event := range batch {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+// purge deletes published events older than the retention period
+func (r *Relay) purge(ctx context.Context) {
+	result := r.db.WithContext(ctx).
+		Where("published_at IS NOT NULL AND published_at < ?", time.Now().Add(-r.cfg.Retention)).
+		Delete(&model.OutboxEvent{})
+	if result.Error != nil {
+		log.ErrorContext(ctx, "Failed to purge published events", "error", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.InfoContext(ctx, "Purged published events", "count", result.RowsAffected)
+	}
+}
+
+// Backoff returns the delay before the given attempt: 1s, 2s, 4s ... capped at maxDelay
+func Backoff(attempts int, maxDelay time.Duration) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	delay := time.Second
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
@@ -0,0 +1,135 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrUnavailable is returned without contacting Redis while the circuit breaker is open
+var ErrUnavailable = errors.New("redis is unavailable")
+
+type probeKey struct{}
+
+// withProbe marks ctx so that its commands pass an open circuit breaker, used to test whether Redis is back
+func withProbe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, probeKey{}, true)
+}
+
+// breaker is a circuit breaker installed as a client hook.
+// It opens after threshold consecutive connection failures and then rejects every command
+// with ErrUnavailable, so requests do not wait for dial and read timeouts while Redis is down.
+// It only closes again when a probe command succeeds.
+type breaker struct {
+	threshold int
+
+	mu       sync.Mutex
+	open     bool
+	failures int
+	lastErr  error
+	openedAt time.Time
+}
+
+var _ redis.Hook = (*breaker)(nil)
+
+func newBreaker(threshold int) *breaker {
+	metrics.RedisCircuitOpen.Set(0)
+	return &breaker{threshold: max(threshold, 1)}
+}
+
+// state reports whether the breaker is open and the error that opened it
+func (b *breaker) state() (open bool, lastErr error, since time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open, b.lastErr, b.openedAt
+}
+
+// trip opens the breaker immediately, used when Redis is unreachable at startup
+func (b *breaker) trip(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = b.threshold
+	b.setOpen(err)
+}
+
+// record counts the outcome of a command that reached Redis
+func (b *breaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !isConnectionError(ctx, err) {
+		if b.open {
+			log.InfoContext(ctx, "Redis is reachable again, closing circuit breaker", "down_for", time.Since(b.openedAt).Round(time.Second).String())
+			metrics.RedisCircuitOpen.Set(0)
+		}
+		b.open, b.failures, b.lastErr = false, 0, nil
+		return
+	}
+	b.failures++
+	b.lastErr = err
+	if !b.open && b.failures >= b.threshold {
+		b.setOpen(err)
+		log.WarnContext(ctx, "Redis is unreachable, opening circuit breaker", "consecutive_failures", b.failures, "error", err)
+	}
+}
+
+func (b *breaker) setOpen(err error) {
+	b.open, b.lastErr, b.openedAt = true, err, time.Now()
+	metrics.RedisCircuitOpen.Set(1)
+}
+
+func (b *breaker) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if probe, _ := ctx.Value(probeKey{}).(bool); probe {
+		return ctx, nil
+	}
+	b.mu.Lock()
+	open := b.open
+	b.mu.Unlock()
+	if open {
+		return ctx, ErrUnavailable
+	}
+	return ctx, nil
+}
+
+func (b *breaker) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if !errors.Is(cmd.Err(), ErrUnavailable) {
+		b.record(ctx, cmd.Err())
+	}
+	return nil
+}
+
+func (b *breaker) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	if len(cmds) == 0 {
+		return ctx, nil
+	}
+	return b.BeforeProcess(ctx, cmds[0])
+}
+
+func (b *breaker) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); isConnectionError(ctx, err) {
+			b.record(ctx, err)
+			return nil
+		}
+	}
+	if len(cmds) > 0 && !errors.Is(cmds[0].Err(), ErrUnavailable) {
+		b.record(ctx, nil)
+	}
+	return nil
+}
+
+// isConnectionError reports whether err means Redis could not be reached.
+// Redis replies such as redis.Nil or WRONGTYPE prove the server is up, and a cancelled
+// request says nothing about Redis either.
+func isConnectionError(ctx context.Context, err error) bool {
+	if err == nil || err == redis.Nil || errors.Is(err, ErrUnavailable) {
+		return false
+	}
+	if _, ok := err.(redis.Error); ok {
+		return false
+	}
+	return !errors.Is(ctx.Err(), context.Canceled)
+}
@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// replyError is an error reply from the Redis server, such as WRONGTYPE
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+func (replyError) RedisError()     {}
+
+var errRefused = errors.New("dial tcp 10.0.0.5:6379: connect: connection refused")
+
+// process runs a command with the given result through the hooks of b
+func process(ctx context.Context, b *breaker, err error) error {
+	ctx, hookErr := b.BeforeProcess(ctx, nil)
+	if hookErr != nil {
+		err = hookErr
+	}
+	cmd := redis.NewStatusCmd(ctx, "get", "key")
+	cmd.SetErr(err)
+	_ = b.AfterProcess(ctx, cmd)
+	return hookErr
+}
+
+func TestBreaker(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	type step struct {
+		ctx context.Context // nil means context.Background()
+		err error
+	}
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []step
+		wantOpen  bool
+	}{
+		{"stays closed below the threshold", 3, []step{{nil, errRefused}, {nil, errRefused}}, false},
+		{"opens at the threshold", 3, []step{{nil, errRefused}, {nil, errRefused}, {nil, errRefused}}, true},
+		{"threshold below one opens on the first failure", 0, []step{{nil, errRefused}}, true},
+		{"success resets the count", 2, []step{{nil, errRefused}, {nil, nil}, {nil, errRefused}}, false},
+		{"nil reply proves Redis is up", 2, []step{{nil, errRefused}, {nil, redis.Nil}, {nil, errRefused}}, false},
+		{"error reply proves Redis is up", 2, []step{{nil, errRefused}, {nil, replyError("WRONGTYPE")}, {nil, errRefused}}, false},
+		{"cancelled requests are not counted", 2, []step{{nil, errRefused}, {cancelled, context.Canceled}, {cancelled, errRefused}}, false},
+		{"open breaker ignores commands", 1, []step{{nil, errRefused}, {nil, nil}}, true},
+		{"successful probe closes the breaker", 1, []step{{nil, errRefused}, {withProbe(context.Background()), nil}}, false},
+		{"failed probe keeps it open", 1, []step{{nil, errRefused}, {withProbe(context.Background()), errRefused}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(tt.threshold)
+			for _, s := range tt.steps {
+				ctx := s.ctx
+				if ctx == nil {
+					ctx = context.Background()
+				}
+				process(ctx, b, s.err)
+			}
+			open, lastErr, _ := b.state()
+			if open != tt.wantOpen {
+				t.Fatalf("open = %v, want %v", open, tt.wantOpen)
+			}
+			if open && !errors.Is(lastErr, errRefused) {
+				t.Errorf("last error = %v, want %v", lastErr, errRefused)
+			}
+		})
+	}
+}
+
+func TestBreakerRejectsWhileOpen(t *testing.T) {
+	b := newBreaker(5)
+	b.trip(errRefused)
+	if err := process(context.Background(), b, nil); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("command on a tripped breaker = %v, want ErrUnavailable", err)
+	}
+	if _, err := b.BeforeProcessPipeline(context.Background(), []redis.Cmder{redis.NewStatusCmd(context.Background())}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("pipeline on a tripped breaker = %v, want ErrUnavailable", err)
+	}
+	if err := process(withProbe(context.Background()), b, nil); err != nil {
+		t.Errorf("probe on a tripped breaker = %v, want it to pass", err)
+	}
+	if err := process(context.Background(), b, nil); err != nil {
+		t.Errorf("command after a successful probe = %v, want it to pass", err)
+	}
+}
+
+func TestBreakerPipeline(t *testing.T) {
+	ok := redis.NewStatusCmd(context.Background())
+	failed := redis.NewStatusCmd(context.Background())
+	failed.SetErr(errRefused)
+
+	tests := []struct {
+		name     string
+		cmds     []redis.Cmder
+		wantOpen bool
+	}{
+		{"all succeed", []redis.Cmder{ok, ok}, false},
+		{"any connection error counts", []redis.Cmder{ok, failed}, true},
+		{"empty pipeline", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(1)
+			_ = b.AfterProcessPipeline(context.Background(), tt.cmds)
+			if open, _, _ := b.state(); open != tt.wantOpen {
+				t.Errorf("open = %v, want %v", open, tt.wantOpen)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"github.com/go-redis/redis/v8"
+)
+
+type startTimeKey struct{}
+
+// metricsHook records Redis command latencies
+type metricsHook struct{}
+
+var _ redis.Hook = metricsHook{}
+
+func (metricsHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startTimeKey{}, time.Now()), nil
+}
+
+func (metricsHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if start, ok := ctx.Value(startTimeKey{}).(time.Time); ok {
+		metrics.RedisCommandDuration.WithLabelValues(cmd.Name(), commandResult(cmd.Err())).Observe(time.Since(start).Seconds())
+	}
+	return nil
+}
+
+func (metricsHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startTimeKey{}, time.Now()), nil
+}
+
+func (metricsHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	if start, ok := ctx.Value(startTimeKey{}).(time.Time); ok {
+		var err error
+		for _, cmd := range cmds {
+			if cmd.Err() != nil && cmd.Err() != redis.Nil {
+				err = cmd.Err()
+				break
+			}
+		}
+		metrics.RedisCommandDuration.WithLabelValues("pipeline", commandResult(err)).Observe(time.Since(start).Seconds())
+	}
+	return nil
+}
+
+// commandResult maps a command error to a label value, redis.Nil is a cache miss rather than a failure
+func commandResult(err error) string {
+	switch err {
+	case nil:
+		return "ok"
+	case redis.Nil:
+		return "nil"
+	default:
+		return "error"
+	}
+}
@@ -2,34 +2,179 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
-	"log"
+	"os"
+	"time"
 
+	"github.com/blackwatch66/user-microservice/config"
+	"github.com/blackwatch66/user-microservice/internal/logger"
 	"github.com/go-redis/redis/v8"
 )
 
-var Rdb *redis.Client
-var Ctx = context.Background()
+var log = logger.For("redis")
 
-// InitRedis initializes Redis connection
-func InitRedis(addr, password string, db int) (*redis.Client, error) {
-	Rdb = redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password, // no password set
-		DB:       db,       // use default DB
-	})
+// Supported values for REDIS_MODE
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// Client is a Redis client guarded by a circuit breaker.
+// While Redis is unreachable commands fail fast with ErrUnavailable; callers decide per feature
+// whether to carry on without Redis or to reject the request.
+type Client struct {
+	redis.UniversalClient
+	breaker *breaker
+}
 
-	// Test connection
-	_, err := Rdb.Ping(Ctx).Result()
+// NewClient creates a client for the Redis deployment described by cfg and pings it.
+// Standalone and Sentinel deployments use a single-node client that follows failovers,
+// Cluster deployments a client that routes every key to the node owning its slot.
+// An unreachable Redis does not fail startup: the client starts with its circuit open
+// and Run keeps probing until Redis answers. Only invalid configuration returns an error.
+func NewClient(ctx context.Context, cfg config.RedisConfig) (*Client, error) {
+	opts, err := universalOptions(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+		return nil, err
+	}
+
+	var rdb redis.UniversalClient
+	switch cfg.Mode {
+	case ModeSentinel:
+		rdb = redis.NewFailoverClient(opts.Failover())
+	case ModeCluster:
+		rdb = redis.NewClusterClient(opts.Cluster())
+	default:
+		rdb = redis.NewClient(opts.Simple())
+	}
+	client := &Client{UniversalClient: rdb, breaker: newBreaker(cfg.BreakerThreshold)}
+	rdb.AddHook(metricsHook{})
+	rdb.AddHook(tracingHook{})
+	rdb.AddHook(client.breaker)
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(withProbe(pingCtx)).Err(); err != nil {
+		client.breaker.trip(err)
+		log.Warn("Redis is not reachable, starting in degraded mode", "mode", cfg.Mode, "addrs", cfg.Addrs, "error", err)
+		return client, nil
+	}
+
+	log.Info("Redis connection established.", "mode", cfg.Mode, "addrs", cfg.Addrs, "db", cfg.DB, "tls", opts.TLSConfig != nil)
+	return client, nil
+}
+
+// Available reports whether commands are currently sent to Redis
+func (c *Client) Available() bool {
+	open, _, _ := c.breaker.state()
+	return !open
+}
+
+// Check pings Redis while the circuit is closed. While it is open Check reports the outage
+// without contacting Redis, the reconnect loop of Run is what probes it.
+func (c *Client) Check(ctx context.Context) error {
+	open, lastErr, since := c.breaker.state()
+	if !open {
+		return c.Ping(ctx).Err()
+	}
+	return fmt.Errorf("%w since %s: %v", ErrUnavailable, since.UTC().Format(time.RFC3339), lastErr)
+}
+
+// Run pings Redis every interval while the circuit is open, until ctx is cancelled.
+// The client's connection pool redials on every probe, the first successful ping closes the circuit.
+func (c *Client) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if c.Available() {
+			continue
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, interval)
+		err := c.Ping(withProbe(pingCtx)).Err()
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			log.DebugContext(ctx, "Redis reconnect attempt failed", "error", err)
+		}
 	}
+}
 
-	log.Println("Redis connection established.")
-	return Rdb, nil
+// universalOptions validates cfg and converts it to client options
+func universalOptions(cfg config.RedisConfig) (*redis.UniversalOptions, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, errors.New("no redis address configured")
+	}
+	switch cfg.Mode {
+	case ModeStandalone:
+		if len(cfg.Addrs) > 1 {
+			return nil, fmt.Errorf("standalone redis takes a single address, got %d; set REDIS_MODE to sentinel or cluster", len(cfg.Addrs))
+		}
+	case ModeSentinel:
+		if cfg.MasterName == "" {
+			return nil, errors.New("sentinel mode requires REDIS_MASTER_NAME")
+		}
+	case ModeCluster:
+		if cfg.DB != 0 {
+			return nil, errors.New("redis cluster only supports database 0")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported redis mode %q, expected standalone, sentinel or cluster", cfg.Mode)
+	}
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &redis.UniversalOptions{
+		Addrs:            cfg.Addrs,
+		DB:               cfg.DB,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		SentinelUsername: cfg.SentinelUsername,
+		SentinelPassword: cfg.SentinelPassword,
+		MasterName:       cfg.MasterName,
+		TLSConfig:        tlsConfig,
+	}, nil
 }
 
-// GetRedis returns the initialized Redis client instance
-func GetRedis() *redis.Client {
-	return Rdb
-} 
\ No newline at end of file
+// newTLSConfig builds the client TLS configuration, nil when TLS is disabled.
+// Without a CA file the server certificate is verified against the system roots.
+func newTLSConfig(cfg config.RedisConfig) (*tls.Config, error) {
+	if !cfg.TLSEnabled {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.TLSServerName,
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+	}
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redis CA file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in redis CA file %s", cfg.TLSCAFile)
+		}
+	}
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if cfg.TLSInsecureSkipVerify {
+		log.Warn("Redis TLS certificate verification is disabled")
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/go-redis/redis/v8"
+)
+
+// ProfileCache holds copies of user profiles and address lists.
+// Entries expire after the TTL given to the implementation and are removed by Invalidate when the user changes.
+type ProfileCache interface {
+	// GetProfile returns ErrNotFound on a cache miss
+	GetProfile(ctx context.Context, userID uint) (*model.User, error)
+	SetProfile(ctx context.Context, user *model.User) error
+	// GetAddresses returns ErrNotFound on a cache miss
+	GetAddresses(ctx context.Context, userID uint) ([]model.Address, error)
+	SetAddresses(ctx context.Context, userID uint, addresses []model.Address) error
+	// Invalidate removes the profile and address list of userID
+	Invalidate(ctx context.Context, userID uint) error
+}
+
+// redisProfileCache stores JSON encoded entries under user:{<user ID>}:profile and user:{<user ID>}:addresses.
+// The hash tag puts both keys of a user in the same Redis Cluster slot, so Invalidate can delete them together.
+type redisProfileCache struct {
+	rdb redis.UniversalClient
+	ttl time.Duration
+}
+
+// NewRedisProfileCache creates a ProfileCache backed by rdb whose entries expire after ttl
+func NewRedisProfileCache(rdb redis.UniversalClient, ttl time.Duration) ProfileCache {
+	return &redisProfileCache{rdb: rdb, ttl: ttl}
+}
+
+func profileKey(userID uint) string {
+	return fmt.Sprintf("user:{%d}:profile", userID)
+}
+
+func addressesKey(userID uint) string {
+	return fmt.Sprintf("user:{%d}:addresses", userID)
+}
+
+func (c *redisProfileCache) GetProfile(ctx context.Context, userID uint) (*model.User, error) {
+	var user model.User
+	if err := c.get(ctx, profileKey(userID), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *redisProfileCache) SetProfile(ctx context.Context, user *model.User) error {
+	return c.set(ctx, profileKey(user.ID), user)
+}
+
+func (c *redisProfileCache) GetAddresses(ctx context.Context, userID uint) ([]model.Address, error) {
+	var addresses []model.Address
+	if err := c.get(ctx, addressesKey(userID), &addresses); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+func (c *redisProfileCache) SetAddresses(ctx context.Context, userID uint, addresses []model.Address) error {
+	return c.set(ctx, addressesKey(userID), addresses)
+}
+
+func (c *redisProfileCache) Invalidate(ctx context.Context, userID uint) error {
+	return c.rdb.Del(ctx, profileKey(userID), addressesKey(userID)).Err()
+}
+
+func (c *redisProfileCache) get(ctx context.Context, key string, value interface{}) error {
+	data, err := c.rdb.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("failed to decode cache entry %s: %w", key, err)
+	}
+	return nil
+}
+
+func (c *redisProfileCache) set(ctx context.Context, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry %s: %w", key, err)
+	}
+	return c.rdb.Set(ctx, key, data, c.ttl).Err()
+}
+
+// MemoryProfileCache is an in-memory ProfileCache for unit tests and local development.
+// Entries are stored JSON encoded like in Redis, so callers never share values with the cache.
+type MemoryProfileCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// NewMemoryProfileCache creates an empty MemoryProfileCache whose entries expire after ttl
+func NewMemoryProfileCache(ttl time.Duration) *MemoryProfileCache {
+	return &MemoryProfileCache{ttl: ttl, entries: make(map[string]memoryCacheEntry)}
+}
+
+func (c *MemoryProfileCache) GetProfile(_ context.Context, userID uint) (*model.User, error) {
+	var user model.User
+	if err := c.get(profileKey(userID), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *MemoryProfileCache) SetProfile(_ context.Context, user *model.User) error {
+	return c.set(profileKey(user.ID), user)
+}
+
+func (c *MemoryProfileCache) GetAddresses(_ context.Context, userID uint) ([]model.Address, error) {
+	var addresses []model.Address
+	if err := c.get(addressesKey(userID), &addresses); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+func (c *MemoryProfileCache) SetAddresses(_ context.Context, userID uint, addresses []model.Address) error {
+	return c.set(addressesKey(userID), addresses)
+}
+
+func (c *MemoryProfileCache) Invalidate(_ context.Context, userID uint) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, profileKey(userID))
+	delete(c.entries, addressesKey(userID))
+	return nil
+}
+
+func (c *MemoryProfileCache) get(key string, value interface{}) error {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+	return json.Unmarshal(entry.data, value)
+}
+
+func (c *MemoryProfileCache) set(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = memoryCacheEntry{data: data, expiresAt: time.Now().Add(c.ttl)}
+	return nil
+}
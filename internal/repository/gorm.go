@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/blackwatch66/user-microservice/internal/audit"
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/blackwatch66/user-microservice/internal/outbox"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormStore implements Store on top of a *gorm.DB, which is a transaction inside Transaction
+type gormStore struct {
+	db    *gorm.DB
+	reads *gorm.DB // used by Replica
+}
+
+// NewGormStore creates a Store backed by db.
+// reads serves the reads of Replica, typically database.ReplicaSet.DB(); nil uses db.
+func NewGormStore(db, reads *gorm.DB) Store {
+	if reads == nil {
+		reads = db
+	}
+	return &gormStore{db: db, reads: reads}
+}
+
+func (s *gormStore) Users() UserRepository        { return gormUserRepository{db: s.db} }
+func (s *gormStore) Addresses() AddressRepository { return gormAddressRepository{db: s.db} }
+func (s *gormStore) LoginHistory() LoginHistoryRepository {
+	return gormLoginHistoryRepository{db: s.db}
+}
+func (s *gormStore) Outbox() OutboxRepository { return gormOutboxRepository{db: s.db} }
+func (s *gormStore) Audit() AuditRepository   { return gormAuditRepository{db: s.db} }
+
+// Transaction runs fn in a database transaction, it is rolled back if fn returns an error
+func (s *gormStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx, reads: tx})
+	})
+}
+
+func (s *gormStore) Replica() Store {
+	return &gormStore{db: s.reads, reads: s.reads}
+}
+
+// translateError maps GORM errors to the repository errors.
+// Unique violations are only recognized when the DB was opened with TranslateError.
+func translateError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fmt.Errorf("%w: %w", ErrDuplicate, err)
+	}
+	return err
+}
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+// updateVersioned saves all columns of value, except omit, provided the stored row is still at *version,
+// and increments *version. Selecting the columns keeps Save from inserting the row when the version does not match.
+func updateVersioned(db *gorm.DB, value interface{}, version *uint64, omit ...string) error {
+	expected := *version
+	*version = expected + 1
+	result := db.Select("*").Omit(omit...).Where("version = ?", expected).Save(value)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		*version = expected
+		return translateError(result.Error)
+	}
+	return nil
+}
+
+func (r gormUserRepository) Create(ctx context.Context, user *model.User) error {
+	user.Version = 1
+	return translateError(r.db.WithContext(ctx).Create(user).Error)
+}
+
+func (r gormUserRepository) FindByID(ctx context.Context, id uint) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r gormUserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r gormUserRepository) Lock(ctx context.Context, id uint) error {
+	// SELECT ... FOR UPDATE; the SQLite driver leaves the clause out, its write transactions already run one at a time
+	var user model.User
+	return translateError(r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, id).Error)
+}
+
+func (r gormUserRepository) Update(ctx context.Context, user *model.User) error {
+	// Omit associations so that saving a user never touches its addresses
+	return updateVersioned(r.db.WithContext(ctx), user, &user.Version, "Addresses")
+}
+
+type gormAddressRepository struct {
+	db *gorm.DB
+}
+
+func (r gormAddressRepository) ListByUser(ctx context.Context, userID uint) ([]model.Address, error) {
+	var addresses []model.Address
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+func (r gormAddressRepository) FindByUser(ctx context.Context, userID, addrID uint) (*model.Address, error) {
+	var addr model.Address
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", addrID, userID).First(&addr).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &addr, nil
+}
+
+func (r gormAddressRepository) Create(ctx context.Context, addr *model.Address) error {
+	addr.Version = 1
+	return translateError(r.db.WithContext(ctx).Create(addr).Error)
+}
+
+func (r gormAddressRepository) Update(ctx context.Context, addr *model.Address) error {
+	return updateVersioned(r.db.WithContext(ctx), addr, &addr.Version)
+}
+
+func (r gormAddressRepository) Delete(ctx context.Context, addrID uint, version uint64) error {
+	result := r.db.WithContext(ctx).Where("version = ?", version).Delete(&model.Address{}, addrID)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return result.Error
+}
+
+type gormLoginHistoryRepository struct {
+	db *gorm.DB
+}
+
+func (r gormLoginHistoryRepository) Create(ctx context.Context, entry *model.LoginHistory) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r gormLoginHistoryRepository) ListByUser(ctx context.Context, userID uint, limit int) ([]model.LoginHistory, error) {
+	var history []model.LoginHistory
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (r gormLoginHistoryRepository) CountSuccessful(ctx context.Context, userID uint, deviceID, country string) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.LoginHistory{}).Where("user_id = ? AND success = ?", userID, true)
+	if deviceID != "" {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	if country != "" {
+		query = query.Where("country = ?", country)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+type gormOutboxRepository struct {
+	db *gorm.DB
+}
+
+func (r gormOutboxRepository) Enqueue(ctx context.Context, event events.Event) error {
+	return outbox.Enqueue(r.db.WithContext(ctx), event)
+}
+
+type gormAuditRepository struct {
+	db *gorm.DB
+}
+
+func (r gormAuditRepository) Record(ctx context.Context, entry audit.Entry) error {
+	return audit.Record(ctx, r.db, entry)
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// IdempotencyRecord is what is stored for an idempotency key: the fingerprint of the first request
+// and, once it has completed, its response
+type IdempotencyRecord struct {
+	Fingerprint string            `json:"fingerprint"`
+	Completed   bool              `json:"completed"`
+	Status      int               `json:"status,omitempty"`       // HTTP status or gRPC code
+	ContentType string            `json:"content_type,omitempty"` // HTTP content type or full name of the protobuf response message
+	Message     string            `json:"message,omitempty"`      // gRPC status message
+	Headers     map[string]string `json:"headers,omitempty"`      // HTTP response headers replayed with the body, such as ETag
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// IdempotencyStore keeps idempotency records
+type IdempotencyStore interface {
+	// Reserve stores an in-progress record for key unless the key is taken.
+	// It returns nil once the key is reserved, otherwise the record stored for the key.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error)
+	// Complete replaces the record of key with the completed one
+	Complete(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error
+	// Release removes key so that the request can be made again
+	Release(ctx context.Context, key string) error
+}
+
+// redisIdempotencyStore keeps JSON encoded records under idempotency:<key>
+type redisIdempotencyStore struct {
+	rdb redis.UniversalClient
+}
+
+// NewRedisIdempotencyStore creates an IdempotencyStore backed by rdb
+func NewRedisIdempotencyStore(rdb redis.UniversalClient) IdempotencyStore {
+	return &redisIdempotencyStore{rdb: rdb}
+}
+
+func idempotencyKey(key string) string {
+	return "idempotency:" + key
+}
+
+func (s *redisIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error) {
+	data, err := json.Marshal(IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+	// The record can expire between SETNX and GET, in that case try to reserve it again
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := s.rdb.SetNX(ctx, idempotencyKey(key), data, ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, nil
+		}
+		existing, err := s.rdb.Get(ctx, idempotencyKey(key)).Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var record IdempotencyRecord
+		if err := json.Unmarshal(existing, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode idempotency record: %w", err)
+		}
+		return &record, nil
+	}
+	return nil, errors.New("failed to reserve idempotency key")
+}
+
+func (s *redisIdempotencyStore) Complete(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, idempotencyKey(key), data, ttl).Err()
+}
+
+func (s *redisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, idempotencyKey(key)).Err()
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore for unit tests and local development
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]memoryIdempotencyRecord
+}
+
+type memoryIdempotencyRecord struct {
+	record    IdempotencyRecord
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty MemoryIdempotencyStore
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]memoryIdempotencyRecord)}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(_ context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[key]; ok && time.Now().Before(existing.expiresAt) {
+		record := existing.record
+		return &record, nil
+	}
+	s.records[key] = memoryIdempotencyRecord{record: IdempotencyRecord{Fingerprint: fingerprint}, expiresAt: time.Now().Add(ttl)}
+	return nil, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = memoryIdempotencyRecord{record: *record, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
@@ -0,0 +1,396 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/audit"
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/blackwatch66/user-microservice/internal/outbox"
+)
+
+// MemoryStore is an in-memory Store for unit tests and local development.
+// Transactions are serialized and rolled back by restoring a snapshot taken when they started.
+type MemoryStore struct {
+	mu    sync.Mutex
+	state *memoryState
+}
+
+type memoryState struct {
+	users        map[uint]model.User
+	addresses    map[uint]model.Address
+	loginHistory []model.LoginHistory
+	outbox       []model.OutboxEvent
+	auditLog     []model.AuditLog
+	lastID       uint64 // shared sequence for all tables
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{state: &memoryState{
+		users:     make(map[uint]model.User),
+		addresses: make(map[uint]model.Address),
+	}}
+}
+
+func (s *MemoryStore) Users() UserRepository        { return memoryUserRepository{view: s.view()} }
+func (s *MemoryStore) Addresses() AddressRepository { return memoryAddressRepository{view: s.view()} }
+func (s *MemoryStore) LoginHistory() LoginHistoryRepository {
+	return memoryLoginHistoryRepository{view: s.view()}
+}
+func (s *MemoryStore) Outbox() OutboxRepository { return memoryOutboxRepository{view: s.view()} }
+func (s *MemoryStore) Audit() AuditRepository   { return memoryAuditRepository{view: s.view()} }
+
+// Transaction runs fn while holding the store lock, all changes are discarded if fn returns an error
+func (s *MemoryStore) Transaction(_ context.Context, fn func(tx Store) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.runTx(fn)
+}
+
+func (s *MemoryStore) runTx(fn func(tx Store) error) error {
+	snapshot := s.state.clone()
+	if err := fn(&memoryTx{store: s}); err != nil {
+		s.state = snapshot
+		return err
+	}
+	return nil
+}
+
+// Replica returns the store itself, there is no replication lag in memory
+func (s *MemoryStore) Replica() Store { return s }
+
+// OutboxEvents returns a copy of all events written to the outbox
+func (s *MemoryStore) OutboxEvents() []model.OutboxEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]model.OutboxEvent(nil), s.state.outbox...)
+}
+
+// AuditLogs returns a copy of all audit log entries
+func (s *MemoryStore) AuditLogs() []model.AuditLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]model.AuditLog(nil), s.state.auditLog...)
+}
+
+// view returns an accessor that locks the store around every operation
+func (s *MemoryStore) view() memoryView {
+	return memoryView{store: s}
+}
+
+// memoryTx is the Store handed to Transaction callbacks, the store lock is already held
+type memoryTx struct {
+	store *MemoryStore
+}
+
+func (t *memoryTx) view() memoryView             { return memoryView{store: t.store, locked: true} }
+func (t *memoryTx) Users() UserRepository        { return memoryUserRepository{view: t.view()} }
+func (t *memoryTx) Addresses() AddressRepository { return memoryAddressRepository{view: t.view()} }
+func (t *memoryTx) Outbox() OutboxRepository     { return memoryOutboxRepository{view: t.view()} }
+func (t *memoryTx) Audit() AuditRepository       { return memoryAuditRepository{view: t.view()} }
+func (t *memoryTx) LoginHistory() LoginHistoryRepository {
+	return memoryLoginHistoryRepository{view: t.view()}
+}
+
+// Transaction nested in a transaction behaves like a savepoint: only its own changes are discarded on error
+func (t *memoryTx) Transaction(_ context.Context, fn func(tx Store) error) error {
+	return t.store.runTx(fn)
+}
+
+func (t *memoryTx) Replica() Store { return t }
+
+// memoryView runs functions against the store state, taking the lock unless it is already held
+type memoryView struct {
+	store  *MemoryStore
+	locked bool
+}
+
+func (v memoryView) do(fn func(st *memoryState) error) error {
+	if !v.locked {
+		v.store.mu.Lock()
+		defer v.store.mu.Unlock()
+	}
+	return fn(v.store.state)
+}
+
+func (st *memoryState) nextID() uint64 {
+	st.lastID++
+	return st.lastID
+}
+
+func (st *memoryState) clone() *memoryState {
+	c := &memoryState{
+		users:        make(map[uint]model.User, len(st.users)),
+		addresses:    make(map[uint]model.Address, len(st.addresses)),
+		loginHistory: append([]model.LoginHistory(nil), st.loginHistory...),
+		outbox:       append([]model.OutboxEvent(nil), st.outbox...),
+		auditLog:     append([]model.AuditLog(nil), st.auditLog...),
+		lastID:       st.lastID,
+	}
+	for id, user := range st.users {
+		c.users[id] = user
+	}
+	for id, addr := range st.addresses {
+		c.addresses[id] = addr
+	}
+	return c
+}
+
+type memoryUserRepository struct {
+	view memoryView
+}
+
+func (r memoryUserRepository) Create(_ context.Context, user *model.User) error {
+	return r.view.do(func(st *memoryState) error {
+		if st.emailTaken(user.Email, 0) {
+			return ErrDuplicate
+		}
+		now := time.Now()
+		user.ID = uint(st.nextID())
+		user.CreatedAt, user.UpdatedAt = now, now
+		user.Version = 1
+		stored := *user
+		stored.Addresses = nil
+		st.users[user.ID] = stored
+		return nil
+	})
+}
+
+// emailTaken mirrors the unique index on the lower-cased email of users that are not soft-deleted
+func (st *memoryState) emailTaken(email string, exceptID uint) bool {
+	for id, stored := range st.users {
+		if id != exceptID && !stored.DeletedAt.Valid && strings.EqualFold(stored.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r memoryUserRepository) FindByID(_ context.Context, id uint) (*model.User, error) {
+	var user model.User
+	err := r.view.do(func(st *memoryState) error {
+		stored, ok := st.users[id]
+		if !ok {
+			return ErrNotFound
+		}
+		user = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Lock only checks that the user exists, memory transactions already run one at a time
+func (r memoryUserRepository) Lock(_ context.Context, id uint) error {
+	return r.view.do(func(st *memoryState) error {
+		if _, ok := st.users[id]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r memoryUserRepository) FindByEmail(_ context.Context, email string) (*model.User, error) {
+	var user model.User
+	err := r.view.do(func(st *memoryState) error {
+		for _, stored := range st.users {
+			if stored.Email == email {
+				user = stored
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r memoryUserRepository) Update(_ context.Context, user *model.User) error {
+	return r.view.do(func(st *memoryState) error {
+		current, ok := st.users[user.ID]
+		if !ok || current.Version != user.Version {
+			return ErrVersionConflict
+		}
+		if st.emailTaken(user.Email, user.ID) {
+			return ErrDuplicate
+		}
+		user.UpdatedAt = time.Now()
+		user.Version++
+		stored := *user
+		stored.Addresses = nil
+		st.users[user.ID] = stored
+		return nil
+	})
+}
+
+// defaultTaken mirrors the unique index on the default address of each user and type
+func (st *memoryState) defaultTaken(addr *model.Address, exceptID uint) bool {
+	if !addr.IsDefault {
+		return false
+	}
+	for id, stored := range st.addresses {
+		if id != exceptID && stored.UserID == addr.UserID && stored.Type == addr.Type && stored.IsDefault {
+			return true
+		}
+	}
+	return false
+}
+
+type memoryAddressRepository struct {
+	view memoryView
+}
+
+func (r memoryAddressRepository) ListByUser(_ context.Context, userID uint) ([]model.Address, error) {
+	var addresses []model.Address
+	err := r.view.do(func(st *memoryState) error {
+		for _, addr := range st.addresses {
+			if addr.UserID == userID {
+				addresses = append(addresses, addr)
+			}
+		}
+		return nil
+	})
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].ID < addresses[j].ID })
+	return addresses, err
+}
+
+func (r memoryAddressRepository) FindByUser(_ context.Context, userID, addrID uint) (*model.Address, error) {
+	var addr model.Address
+	err := r.view.do(func(st *memoryState) error {
+		stored, ok := st.addresses[addrID]
+		if !ok || stored.UserID != userID {
+			return ErrNotFound
+		}
+		addr = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &addr, nil
+}
+
+func (r memoryAddressRepository) Create(_ context.Context, addr *model.Address) error {
+	return r.view.do(func(st *memoryState) error {
+		if st.defaultTaken(addr, 0) {
+			return ErrDuplicate
+		}
+		now := time.Now()
+		addr.ID = uint(st.nextID())
+		addr.CreatedAt, addr.UpdatedAt = now, now
+		addr.Version = 1
+		st.addresses[addr.ID] = *addr
+		return nil
+	})
+}
+
+func (r memoryAddressRepository) Update(_ context.Context, addr *model.Address) error {
+	return r.view.do(func(st *memoryState) error {
+		stored, ok := st.addresses[addr.ID]
+		if !ok || stored.Version != addr.Version {
+			return ErrVersionConflict
+		}
+		if st.defaultTaken(addr, addr.ID) {
+			return ErrDuplicate
+		}
+		addr.UpdatedAt = time.Now()
+		addr.Version++
+		st.addresses[addr.ID] = *addr
+		return nil
+	})
+}
+
+func (r memoryAddressRepository) Delete(_ context.Context, addrID uint, version uint64) error {
+	return r.view.do(func(st *memoryState) error {
+		stored, ok := st.addresses[addrID]
+		if !ok || stored.Version != version {
+			return ErrVersionConflict
+		}
+		delete(st.addresses, addrID)
+		return nil
+	})
+}
+
+type memoryLoginHistoryRepository struct {
+	view memoryView
+}
+
+func (r memoryLoginHistoryRepository) Create(_ context.Context, entry *model.LoginHistory) error {
+	return r.view.do(func(st *memoryState) error {
+		entry.ID = st.nextID()
+		entry.CreatedAt = time.Now()
+		st.loginHistory = append(st.loginHistory, *entry)
+		return nil
+	})
+}
+
+func (r memoryLoginHistoryRepository) ListByUser(_ context.Context, userID uint, limit int) ([]model.LoginHistory, error) {
+	var history []model.LoginHistory
+	err := r.view.do(func(st *memoryState) error {
+		for i := len(st.loginHistory) - 1; i >= 0 && len(history) < limit; i-- {
+			if st.loginHistory[i].UserID == userID {
+				history = append(history, st.loginHistory[i])
+			}
+		}
+		return nil
+	})
+	return history, err
+}
+
+func (r memoryLoginHistoryRepository) CountSuccessful(_ context.Context, userID uint, deviceID, country string) (int64, error) {
+	var count int64
+	err := r.view.do(func(st *memoryState) error {
+		for _, entry := range st.loginHistory {
+			if entry.UserID != userID || !entry.Success {
+				continue
+			}
+			if (deviceID == "" || entry.DeviceID == deviceID) && (country == "" || strings.EqualFold(entry.Country, country)) {
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+type memoryOutboxRepository struct {
+	view memoryView
+}
+
+func (r memoryOutboxRepository) Enqueue(_ context.Context, event events.Event) error {
+	row, err := outbox.NewEvent(event)
+	if err != nil {
+		return err
+	}
+	return r.view.do(func(st *memoryState) error {
+		row.ID = st.nextID()
+		row.CreatedAt = time.Now()
+		st.outbox = append(st.outbox, *row)
+		return nil
+	})
+}
+
+type memoryAuditRepository struct {
+	view memoryView
+}
+
+func (r memoryAuditRepository) Record(ctx context.Context, entry audit.Entry) error {
+	row, err := audit.NewLog(ctx, entry)
+	if err != nil {
+		return err
+	}
+	return r.view.do(func(st *memoryState) error {
+		row.ID = st.nextID()
+		st.auditLog = append(st.auditLog, *row)
+		return nil
+	})
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+func TestMemoryTransactionRollback(t *testing.T) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name      string
+		fn        func(tx Store) error
+		wantUsers int
+	}{
+		{"commit", func(tx Store) error {
+			return tx.Users().Create(ctx, &model.User{Email: "ada@example.com"})
+		}, 1},
+		{"rollback", func(tx Store) error {
+			if err := tx.Users().Create(ctx, &model.User{Email: "ada@example.com"}); err != nil {
+				return err
+			}
+			if err := tx.Outbox().Enqueue(ctx, events.UserRegistered{UserID: 1}); err != nil {
+				return err
+			}
+			return errAbort
+		}, 0},
+		{"nested rollback keeps the outer changes", func(tx Store) error {
+			if err := tx.Users().Create(ctx, &model.User{Email: "ada@example.com"}); err != nil {
+				return err
+			}
+			err := tx.Transaction(ctx, func(inner Store) error {
+				if err := inner.Users().Create(ctx, &model.User{Email: "grace@example.com"}); err != nil {
+					return err
+				}
+				return errAbort
+			})
+			if !errors.Is(err, errAbort) {
+				t.Errorf("nested Transaction() = %v, want %v", err, errAbort)
+			}
+			return nil
+		}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			store.Transaction(ctx, tt.fn)
+			var users int
+			for _, email := range []string{"ada@example.com", "grace@example.com"} {
+				if _, err := store.Users().FindByEmail(ctx, email); err == nil {
+					users++
+				}
+			}
+			if users != tt.wantUsers {
+				t.Errorf("%d users committed, want %d", users, tt.wantUsers)
+			}
+			if tt.wantUsers == 0 && len(store.OutboxEvents()) != 0 {
+				t.Error("outbox event committed by a rolled back transaction")
+			}
+		})
+	}
+}
+
+func TestMemoryConstraints(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	user := &model.User{Email: "ada@example.com"}
+	if err := store.Users().Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	home := &model.Address{UserID: user.ID, Type: model.AddressTypeShipping, IsDefault: true}
+	if err := store.Addresses().Create(ctx, home); err != nil {
+		t.Fatal(err)
+	}
+	stale := *home
+
+	tests := []struct {
+		name string
+		op   func() error
+		want error
+	}{
+		{"email unique ignoring case", func() error {
+			return store.Users().Create(ctx, &model.User{Email: "ADA@example.com"})
+		}, ErrDuplicate},
+		{"one default per user and type", func() error {
+			return store.Addresses().Create(ctx, &model.Address{UserID: user.ID, Type: model.AddressTypeShipping, IsDefault: true})
+		}, ErrDuplicate},
+		{"default of another type", func() error {
+			return store.Addresses().Create(ctx, &model.Address{UserID: user.ID, Type: model.AddressTypeBilling, IsDefault: true})
+		}, nil},
+		{"update at the current version", func() error {
+			home.City = "Springfield"
+			return store.Addresses().Update(ctx, home)
+		}, nil},
+		{"update at a stale version", func() error {
+			return store.Addresses().Update(ctx, &stale)
+		}, ErrVersionConflict},
+		{"delete at a stale version", func() error {
+			return store.Addresses().Delete(ctx, stale.ID, stale.Version)
+		}, ErrVersionConflict},
+		{"unknown user", func() error {
+			_, err := store.Users().FindByID(ctx, 999)
+			return err
+		}, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
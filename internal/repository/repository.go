@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/audit"
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate is returned when a write violates a unique constraint
+	ErrDuplicate = errors.New("duplicate record")
+	// ErrVersionConflict is returned when a record was changed or deleted since the version being written was read
+	ErrVersionConflict = errors.New("record was modified concurrently")
+)
+
+// UserRepository persists user accounts
+type UserRepository interface {
+	Create(ctx context.Context, user *model.User) error
+	FindByID(ctx context.Context, id uint) (*model.User, error)
+	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	// Update saves user if the stored version still equals user.Version and increments it,
+	// otherwise it returns ErrVersionConflict
+	Update(ctx context.Context, user *model.User) error
+	// Lock locks the row of user id until the transaction ends, so that transactions that check the
+	// address book of the same user before writing run one after another. It returns ErrNotFound for unknown users.
+	Lock(ctx context.Context, id uint) error
+}
+
+// AddressRepository persists user addresses
+type AddressRepository interface {
+	ListByUser(ctx context.Context, userID uint) ([]model.Address, error)
+	// FindByUser returns the address only if it belongs to userID
+	FindByUser(ctx context.Context, userID, addrID uint) (*model.Address, error)
+	Create(ctx context.Context, addr *model.Address) error
+	// Update saves addr if the stored version still equals addr.Version and increments it,
+	// otherwise it returns ErrVersionConflict
+	Update(ctx context.Context, addr *model.Address) error
+	// Delete removes the address if it is still at version, otherwise it returns ErrVersionConflict
+	Delete(ctx context.Context, addrID uint, version uint64) error
+}
+
+// LoginHistoryRepository persists login attempts
+type LoginHistoryRepository interface {
+	Create(ctx context.Context, entry *model.LoginHistory) error
+	// ListByUser returns the most recent entries first
+	ListByUser(ctx context.Context, userID uint, limit int) ([]model.LoginHistory, error)
+	// CountSuccessful counts successful logins of userID, optionally restricted to a device and/or country
+	CountSuccessful(ctx context.Context, userID uint, deviceID, country string) (int64, error)
+}
+
+// OutboxRepository writes domain events to the transactional outbox
+type OutboxRepository interface {
+	Enqueue(ctx context.Context, event events.Event) error
+}
+
+// AuditRepository appends entries to the audit log
+type AuditRepository interface {
+	Record(ctx context.Context, entry audit.Entry) error
+}
+
+// Store gives access to all repositories.
+// The Store passed to the Transaction callback returns repositories bound to that transaction,
+// so that all writes made through it are committed or rolled back together.
+type Store interface {
+	Users() UserRepository
+	Addresses() AddressRepository
+	LoginHistory() LoginHistoryRepository
+	Outbox() OutboxRepository
+	Audit() AuditRepository
+	Transaction(ctx context.Context, fn func(tx Store) error) error
+	// Replica returns a Store whose reads may be served by a read replica and lag behind recent writes.
+	// Use it only for reads that tolerate stale data; inside a transaction it returns the transaction itself.
+	Replica() Store
+}
+
+// SessionStore keeps the currently issued token of each user
+type SessionStore interface {
+	Save(ctx context.Context, userID uint, token string, ttl time.Duration) error
+	// Get returns ErrNotFound when no token is stored or it has expired
+	Get(ctx context.Context, userID uint) (string, error)
+	Delete(ctx context.Context, userID uint) error
+	// RevokeBefore revokes the tokens of userID issued at or before t, in whole seconds like the iat claim.
+	// The record expires after ttl, by then every token it revokes has expired as well.
+	RevokeBefore(ctx context.Context, userID uint, t time.Time, ttl time.Duration) error
+	// RevokedBefore returns the time passed to the latest RevokeBefore, ErrNotFound when there is none
+	RevokedBefore(ctx context.Context, userID uint) (time.Time, error)
+}
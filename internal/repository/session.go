@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisSessionStore keeps tokens in Redis under jwt:<user ID>
+// and revocations as Unix seconds under jwt:<user ID>:revoked_before
+type redisSessionStore struct {
+	rdb redis.UniversalClient
+}
+
+// NewRedisSessionStore creates a SessionStore backed by rdb
+func NewRedisSessionStore(rdb redis.UniversalClient) SessionStore {
+	return &redisSessionStore{rdb: rdb}
+}
+
+func sessionKey(userID uint) string {
+	return fmt.Sprintf("jwt:%d", userID)
+}
+
+func revokedBeforeKey(userID uint) string {
+	return fmt.Sprintf("jwt:%d:revoked_before", userID)
+}
+
+func (s *redisSessionStore) Save(ctx context.Context, userID uint, token string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, sessionKey(userID), token, ttl).Err()
+}
+
+func (s *redisSessionStore) Get(ctx context.Context, userID uint) (string, error) {
+	token, err := s.rdb.Get(ctx, sessionKey(userID)).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	return token, err
+}
+
+func (s *redisSessionStore) Delete(ctx context.Context, userID uint) error {
+	return s.rdb.Del(ctx, sessionKey(userID)).Err()
+}
+
+func (s *redisSessionStore) RevokeBefore(ctx context.Context, userID uint, t time.Time, ttl time.Duration) error {
+	return s.rdb.Set(ctx, revokedBeforeKey(userID), t.Unix(), ttl).Err()
+}
+
+func (s *redisSessionStore) RevokedBefore(ctx context.Context, userID uint) (time.Time, error) {
+	seconds, err := s.rdb.Get(ctx, revokedBeforeKey(userID)).Int64()
+	if err == redis.Nil {
+		return time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// MemorySessionStore is an in-memory SessionStore for unit tests and local development
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[uint]memorySession
+	revoked  map[uint]memoryRevocation
+}
+
+type memoryRevocation struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+type memorySession struct {
+	token     string
+	expiresAt time.Time
+}
+
+// NewMemorySessionStore creates an empty MemorySessionStore
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[uint]memorySession), revoked: make(map[uint]memoryRevocation)}
+}
+
+func (s *MemorySessionStore) Save(_ context.Context, userID uint, token string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[userID] = memorySession{token: token, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemorySessionStore) Get(_ context.Context, userID uint) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[userID]
+	if !ok || time.Now().After(session.expiresAt) {
+		delete(s.sessions, userID)
+		return "", ErrNotFound
+	}
+	return session.token, nil
+}
+
+func (s *MemorySessionStore) Delete(_ context.Context, userID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, userID)
+	return nil
+}
+
+func (s *MemorySessionStore) RevokeBefore(_ context.Context, userID uint, t time.Time, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[userID] = memoryRevocation{before: t.Truncate(time.Second), expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemorySessionStore) RevokedBefore(_ context.Context, userID uint) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	revocation, ok := s.revoked[userID]
+	if !ok || time.Now().After(revocation.expiresAt) {
+		delete(s.revoked, userID)
+		return time.Time{}, ErrNotFound
+	}
+	return revocation.before, nil
+}
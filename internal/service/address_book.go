@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/blackwatch66/user-microservice/internal/country"
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+// DuplicateAddressError is returned by AddUserAddress when the new address duplicates a stored address of the same type.
+// Exact is set when the stored address already has every value of the new one, the add can then be treated as done.
+type DuplicateAddressError struct {
+	Existing model.Address
+	Exact    bool
+}
+
+func (e *DuplicateAddressError) Error() string {
+	return fmt.Sprintf("address duplicates address %d", e.Existing.ID)
+}
+
+// streetWords maps common words of street addresses to one spelling, words mapped to "" are left out,
+// so that "12 Main Street, Apt. 4" and "12 main st #4" compare equal
+var streetWords = map[string]string{
+	"street": "st", "avenue": "ave", "av": "ave", "road": "rd", "boulevard": "blvd", "drive": "dr",
+	"lane": "ln", "court": "ct", "place": "pl", "square": "sq", "terrace": "ter", "highway": "hwy",
+	"parkway": "pkwy", "circle": "cir", "north": "n", "south": "s", "east": "e", "west": "w",
+	"floor": "fl", "building": "bldg", "strasse": "str", "straße": "str",
+	"apartment": "", "apt": "", "unit": "", "suite": "", "ste": "", "number": "", "no": "",
+}
+
+// duplicateKey identifies an address for duplicate detection: addresses of the same type with the same
+// country, postal code, subdivision and city and the same street up to case, punctuation and common
+// abbreviations are duplicates. Addresses stored before normalization are normalized for the comparison.
+func duplicateKey(addr *model.Address) string {
+	countryCode, state := strings.ToUpper(strings.TrimSpace(addr.Country)), addr.State
+	postalCode := addr.PostalCode
+	if c, ok := country.Lookup(addr.Country); ok {
+		countryCode = c.Code
+		if sub, ok := c.Subdivision(addr.State); ok {
+			state = sub.Code
+		}
+		if normalized, ok := c.NormalizePostalCode(addr.PostalCode); ok {
+			postalCode = normalized
+		}
+	}
+	postalCode = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, postalCode)
+	return strings.Join([]string{addr.Type, countryCode, postalCode, foldWords(state), foldWords(addr.City), foldStreet(addr.Street)}, "|")
+}
+
+// foldWords lower-cases s and reduces punctuation and whitespace to single spaces
+func foldWords(s string) string {
+	return strings.Join(words(s), " ")
+}
+
+func foldStreet(street string) string {
+	var folded []string
+	for _, word := range words(street) {
+		if short, ok := streetWords[word]; ok {
+			word = short
+		} else {
+			// German compounds such as "Hauptstraße" end in the street word
+			for _, long := range []string{"straße", "strasse"} {
+				if strings.HasSuffix(word, long) {
+					word = strings.TrimSuffix(word, long) + "str"
+				}
+			}
+		}
+		if word != "" {
+			folded = append(folded, word)
+		}
+	}
+	return strings.Join(folded, " ")
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// findDuplicate returns the stored address that addr duplicates, if any
+func findDuplicate(addr *model.Address, addresses []model.Address) *DuplicateAddressError {
+	key := duplicateKey(addr)
+	for _, existing := range addresses {
+		if duplicateKey(&existing) != key {
+			continue
+		}
+		exact := existing.Street == addr.Street && existing.City == addr.City && existing.State == addr.State &&
+			existing.PostalCode == addr.PostalCode && existing.Country == addr.Country &&
+			(addr.Label == "" || existing.Label == addr.Label) && (!addr.IsDefault || existing.IsDefault)
+		return &DuplicateAddressError{Existing: existing, Exact: exact}
+	}
+	return nil
+}
+
+// selectDuplicates returns the addresses to merge into target: the addresses with the given IDs, which must all
+// duplicate target, or every duplicate of target when no IDs are given
+func selectDuplicates(target *model.Address, addresses []model.Address, ids []uint) ([]model.Address, error) {
+	key := duplicateKey(target)
+	byID := make(map[uint]model.Address, len(addresses))
+	var duplicates []model.Address
+	for _, addr := range addresses {
+		byID[addr.ID] = addr
+		if len(ids) == 0 && addr.ID != target.ID && duplicateKey(&addr) == key {
+			duplicates = append(duplicates, addr)
+		}
+	}
+	if len(ids) == 0 {
+		return duplicates, nil
+	}
+
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		addr, ok := byID[id]
+		if !ok {
+			return nil, ErrAddressNotFound
+		}
+		if id == target.ID || duplicateKey(&addr) != key {
+			message := fmt.Sprintf("address %d is not a duplicate of address %d", id, target.ID)
+			return nil, &ValidationError{Message: "address_ids " + message, Fields: map[string]string{"address_ids": message}}
+		}
+		if !seen[id] {
+			seen[id] = true
+			duplicates = append(duplicates, addr)
+		}
+	}
+	return duplicates, nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+func TestDuplicateKey(t *testing.T) {
+	base := model.Address{Type: model.AddressTypeShipping, Street: "12 Main Street, Apt. 4", City: "Springfield", State: "IL", PostalCode: "62701", Country: "US"}
+	with := func(change func(addr *model.Address)) model.Address {
+		addr := base
+		change(&addr)
+		return addr
+	}
+	tests := []struct {
+		name      string
+		other     model.Address
+		duplicate bool
+	}{
+		{"same address", base, true},
+		{"case", with(func(a *model.Address) { a.Street, a.City = "12 MAIN STREET, APT. 4", "springfield" }), true},
+		{"punctuation and whitespace", with(func(a *model.Address) { a.Street = "12  Main Street Apt 4." }), true},
+		{"abbreviated street words", with(func(a *model.Address) { a.Street = "12 main st #4" }), true},
+		{"state name and code", with(func(a *model.Address) { a.State = "Illinois" }), true},
+		{"country name and code", with(func(a *model.Address) { a.Country = "United States" }), true},
+		{"label and default are ignored", with(func(a *model.Address) { a.Label, a.IsDefault = "Home", true }), true},
+		{"street word order matters", with(func(a *model.Address) { a.Street = "Main Street 12, Apt. 4" }), false},
+		{"different house number", with(func(a *model.Address) { a.Street = "14 Main Street, Apt. 4" }), false},
+		{"different postal code", with(func(a *model.Address) { a.PostalCode = "62702" }), false},
+		{"different type", with(func(a *model.Address) { a.Type = model.AddressTypeBilling }), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := duplicateKey(&base) == duplicateKey(&tt.other)
+			if got != tt.duplicate {
+				t.Errorf("duplicate = %v, want %v (keys %q and %q)", got, tt.duplicate, duplicateKey(&base), duplicateKey(&tt.other))
+			}
+		})
+	}
+}
+
+func TestDuplicateKeyNormalization(t *testing.T) {
+	// Addresses stored before normalization are normalized for the comparison
+	stored := model.Address{Type: model.AddressTypeShipping, Street: "1000 Hauptstraße", City: "Berlin", PostalCode: "10117", Country: "de"}
+	added := model.Address{Type: model.AddressTypeShipping, Street: "1000 Hauptstr.", City: "Berlin", PostalCode: "10117", Country: "DE"}
+	if duplicateKey(&stored) != duplicateKey(&added) {
+		t.Errorf("keys %q and %q differ, want the German street word folded", duplicateKey(&stored), duplicateKey(&added))
+	}
+
+	canadian := model.Address{Type: model.AddressTypeShipping, Street: "1 Rue Peel", City: "Montreal", State: "Quebec", PostalCode: "h3z2y7", Country: "CA"}
+	normalized := canadian
+	normalized.State, normalized.PostalCode = "QC", "H3Z 2Y7"
+	if duplicateKey(&canadian) != duplicateKey(&normalized) {
+		t.Errorf("keys %q and %q differ, want postal code and province normalized", duplicateKey(&canadian), duplicateKey(&normalized))
+	}
+}
+
+func TestMergeUserAddresses(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// the stored addresses: 0 is the merge target, 1 and 2 duplicate it, 3 is another address
+		labels      [4]string
+		defaultIdx  int // index of the default shipping address
+		ids         []int
+		wantMerged  []int
+		wantDefault bool
+		wantLabel   string
+		wantErr     bool
+	}{
+		{"all duplicates, the default moves to the survivor", [4]string{"", "Work", "Home", ""}, 1, nil, []int{1, 2}, true, "Work", false},
+		{"survivor keeps its label and default", [4]string{"Office", "Work", "", ""}, 0, nil, []int{1, 2}, true, "Office", false},
+		{"default elsewhere stays there", [4]string{"", "", "Home", ""}, 3, nil, []int{1, 2}, false, "Home", false},
+		{"selected duplicates only", [4]string{"", "", "Home", ""}, 2, []int{1}, []int{1}, false, "", false},
+		{"not a duplicate", [4]string{"", "", "", ""}, 0, []int{3}, nil, true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, store := newTestService(t)
+			userID := mustRegister(t, svc, "ada@example.com")
+			streets := [4]string{"12 Main Street", "12 main st", "12 Main St.", "14 Main Street"}
+			var ids [4]uint
+			for i := range streets {
+				addr := testAddress()
+				addr.UserID, addr.Type = userID, model.AddressTypeShipping
+				addr.Street, addr.Label, addr.IsDefault = streets[i], tt.labels[i], i == tt.defaultIdx
+				// Stored directly, AddUserAddress would reject the duplicates
+				if err := store.Addresses().Create(ctx, &addr); err != nil {
+					t.Fatal(err)
+				}
+				ids[i] = addr.ID
+			}
+			var selected []uint
+			for _, i := range tt.ids {
+				selected = append(selected, ids[i])
+			}
+			outbox := len(store.OutboxEvents())
+
+			survivor, merged, err := svc.MergeUserAddresses(ctx, userID, ids[0], 0, selected)
+			if tt.wantErr {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("MergeUserAddresses() = %v, want a ValidationError", err)
+				}
+				if len(store.OutboxEvents()) != outbox {
+					t.Error("rejected merge wrote events")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if survivor.ID != ids[0] || survivor.Street != streets[0] {
+				t.Errorf("survivor = %d %q, want %d %q", survivor.ID, survivor.Street, ids[0], streets[0])
+			}
+			if survivor.IsDefault != tt.wantDefault || survivor.Label != tt.wantLabel {
+				t.Errorf("survivor default = %v label = %q, want %v %q", survivor.IsDefault, survivor.Label, tt.wantDefault, tt.wantLabel)
+			}
+			var wantMerged []uint
+			for _, i := range tt.wantMerged {
+				wantMerged = append(wantMerged, ids[i])
+			}
+			if len(merged) != len(wantMerged) {
+				t.Fatalf("merged %v, want %v", merged, wantMerged)
+			}
+			for i := range merged {
+				if merged[i] != wantMerged[i] {
+					t.Errorf("merged %v, want %v", merged, wantMerged)
+				}
+			}
+
+			remaining, err := svc.GetUserAddresses(ctx, userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(remaining) != 4-len(merged) {
+				t.Errorf("%d addresses remain, want %d", len(remaining), 4-len(merged))
+			}
+			defaults := 0
+			for _, addr := range remaining {
+				if addr.IsDefault {
+					defaults++
+				}
+			}
+			if defaults != 1 {
+				t.Errorf("%d default shipping addresses remain, want 1", defaults)
+			}
+			written := store.OutboxEvents()[outbox:]
+			if last := written[len(written)-1]; last.EventType != events.TypeAddressMerged {
+				t.Errorf("last event = %s, want %s", last.EventType, events.TypeAddressMerged)
+			}
+		})
+	}
+}
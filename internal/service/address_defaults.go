@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/blackwatch66/user-microservice/internal/repository"
+)
+
+// Every user with at least one address of a type has exactly one default address of that type.
+// The database rejects a second default per user and type; the functions below keep at least one
+// by moving the default inside the transaction that adds, changes or deletes an address.
+
+// errDefaultRequired is the validation problem reported when the default address is unset directly
+const errDefaultRequired = "cannot be unset, make another address of this type the default instead"
+
+// addressesOfType returns the addresses of userID with the given type, except the address with ID except
+func addressesOfType(ctx context.Context, tx repository.Store, userID uint, addrType string, except uint) ([]model.Address, error) {
+	addresses, err := tx.Addresses().ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error finding addresses: %w", err)
+	}
+	return ofType(addresses, addrType, except), nil
+}
+
+// ofType returns the addresses with the given type, except the address with ID except
+func ofType(addresses []model.Address, addrType string, except uint) []model.Address {
+	var matching []model.Address
+	for _, addr := range addresses {
+		if addr.Type == addrType && addr.ID != except {
+			matching = append(matching, addr)
+		}
+	}
+	return matching
+}
+
+// hasDefault reports whether one of addresses is the default
+func hasDefault(addresses []model.Address) bool {
+	for _, addr := range addresses {
+		if addr.IsDefault {
+			return true
+		}
+	}
+	return false
+}
+
+// clearDefault unsets the default among addresses
+func clearDefault(ctx context.Context, tx repository.Store, addresses []model.Address) error {
+	for i := range addresses {
+		if addresses[i].IsDefault {
+			if err := setDefault(ctx, tx, &addresses[i], false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// promoteDefault makes the most recently added of addresses the default, if there is any
+func promoteDefault(ctx context.Context, tx repository.Store, addresses []model.Address) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+	newest := &addresses[0]
+	for i := range addresses {
+		if addresses[i].ID > newest.ID {
+			newest = &addresses[i]
+		}
+	}
+	return setDefault(ctx, tx, newest, true)
+}
+
+// setDefault changes the default flag of an address other than the one being written and emits its event
+func setDefault(ctx context.Context, tx repository.Store, addr *model.Address, isDefault bool) error {
+	addr.IsDefault = isDefault
+	if err := tx.Addresses().Update(ctx, addr); err != nil {
+		return addressWriteError(err, "update default address")
+	}
+	return tx.Outbox().Enqueue(ctx, events.AddressUpdated{UserID: addr.UserID, Address: events.NewAddress(*addr)})
+}
+
+// addressWriteError maps the errors of concurrent address writes to ErrVersionMismatch.
+// A duplicate means another request made a different address of the same type the default meanwhile.
+func addressWriteError(err error, action string) error {
+	if errors.Is(err, repository.ErrVersionConflict) || errors.Is(err, repository.ErrDuplicate) {
+		return ErrVersionMismatch
+	}
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackwatch66/user-microservice/internal/country"
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+// normalizeAddress applies the rules of the address's country: the country is stored as its
+// ISO 3166-1 alpha-2 code, the postal code and subdivision are checked and rewritten to their
+// canonical form, and repeated whitespace is collapsed in every field
+func normalizeAddress(addr *model.Address) error {
+	for _, field := range []*string{&addr.Street, &addr.City, &addr.State, &addr.PostalCode, &addr.Country, &addr.Label} {
+		*field = strings.Join(strings.Fields(*field), " ")
+	}
+
+	errs := fieldErrors{}
+	c, ok := country.Lookup(addr.Country)
+	if !ok {
+		errs["country"] = "must be an ISO 3166-1 alpha-2 code or the English name of a country"
+		return errs.err()
+	}
+	addr.Country = c.Code
+
+	if addr.PostalCode == "" {
+		if c.PostalRequired() {
+			errs["postal_code"] = "is required in " + c.Name
+		}
+	} else if postalCode, ok := c.NormalizePostalCode(addr.PostalCode); ok {
+		addr.PostalCode = postalCode
+	} else {
+		errs["postal_code"] = fmt.Sprintf("is not a valid postal code in %s, for example %s", c.Name, c.PostalExample)
+	}
+
+	if c.HasSubdivisions() {
+		if sub, ok := c.Subdivision(addr.State); ok {
+			addr.State = c.SubdivisionValue(sub)
+		} else {
+			errs["state"] = fmt.Sprintf("must be a %s of %s", c.SubdivisionLabel, c.Name)
+		}
+	}
+	return errs.err()
+}
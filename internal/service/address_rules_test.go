@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		addr      model.Address
+		want      model.Address
+		wantField string // field reported invalid, empty when the address is valid
+	}{
+		{"canadian postal code is reformatted",
+			model.Address{Street: "1000 Rue De La Gauchetière", City: "Montréal", State: "Quebec", PostalCode: "h3z2y7", Country: "ca"},
+			model.Address{Street: "1000 Rue De La Gauchetière", City: "Montréal", State: "QC", PostalCode: "H3Z 2Y7", Country: "CA"}, ""},
+		{"brazilian postal code gets its dash",
+			model.Address{Street: "Av. Paulista, 1578", City: "São Paulo", State: "sp", PostalCode: "01310200", Country: "Brazil"},
+			model.Address{Street: "Av. Paulista, 1578", City: "São Paulo", State: "SP", PostalCode: "01310-200", Country: "BR"}, ""},
+		{"us state name becomes its code",
+			model.Address{Street: "1 Main St", City: "Springfield", State: "illinois", PostalCode: "62701", Country: "USA"},
+			model.Address{Street: "1 Main St", City: "Springfield", State: "IL", PostalCode: "62701", Country: "US"}, ""},
+		{"whitespace is collapsed",
+			model.Address{Street: "  1   Main St ", City: "Springfield ", State: " IL", PostalCode: " 62701 ", Country: " US ", Label: " Home  office "},
+			model.Address{Street: "1 Main St", City: "Springfield", State: "IL", PostalCode: "62701", Country: "US", Label: "Home office"}, ""},
+		{"country without rules keeps its fields",
+			model.Address{Street: "Norzin Lam", City: "Thimphu", State: "anything", PostalCode: "x1", Country: "Bhutan"},
+			model.Address{Street: "Norzin Lam", City: "Thimphu", State: "anything", PostalCode: "X1", Country: "BT"}, ""},
+		{"unknown country",
+			model.Address{Street: "1 Main St", City: "Poseidonis", PostalCode: "12345", Country: "Atlantis"}, model.Address{}, "country"},
+		{"missing required postal code",
+			model.Address{Street: "1 Main St", City: "Springfield", State: "IL", Country: "US"}, model.Address{}, "postal_code"},
+		{"postal code in the wrong format",
+			model.Address{Street: "1 Main St", City: "Springfield", State: "IL", PostalCode: "6270", Country: "US"}, model.Address{}, "postal_code"},
+		{"unknown subdivision",
+			model.Address{Street: "1 Main St", City: "Springfield", State: "Ontario", PostalCode: "62701", Country: "US"}, model.Address{}, "state"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := tt.addr
+			err := normalizeAddress(&addr)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("normalizeAddress() = %v", err)
+				}
+				if !reflect.DeepEqual(addr, tt.want) {
+					t.Errorf("normalizeAddress() = %+v, want %+v", addr, tt.want)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("normalizeAddress() = %v, want a ValidationError", err)
+			}
+			if _, ok := validationErr.Fields[tt.wantField]; !ok || len(validationErr.Fields) != 1 {
+				t.Errorf("invalid fields = %v, want only %s", validationErr.Fields, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"gorm.io/gorm"
+)
+
+// AuditFilter selects audit log entries, zero values do not filter
+type AuditFilter struct {
+	ActorID      uint
+	ActorType    string
+	TargetUserID uint
+	Action       string
+	Outcome      string
+	IP           string
+	RequestID    string
+	From         time.Time // inclusive
+	To           time.Time // exclusive
+	BeforeID     uint64    // keyset pagination cursor, only entries with a smaller ID are returned
+	Limit        int
+}
+
+// AuditService provides read access to the audit log
+type AuditService interface {
+	Query(ctx context.Context, filter AuditFilter) ([]model.AuditLog, error)
+}
+
+// auditServiceImpl implements the AuditService interface
+type auditServiceImpl struct {
+	db *gorm.DB
+}
+
+// NewAuditService creates a new AuditService instance
+func NewAuditService(db *gorm.DB) AuditService {
+	return &auditServiceImpl{db: db}
+}
+
+// Query returns matching entries, newest first
+func (s *auditServiceImpl) Query(ctx context.Context, filter AuditFilter) ([]model.AuditLog, error) {
+	query := s.db.WithContext(ctx)
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.ActorType != "" {
+		query = query.Where("actor_type = ?", filter.ActorType)
+	}
+	if filter.TargetUserID != 0 {
+		query = query.Where("target_user_id = ?", filter.TargetUserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("occurred_at >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("occurred_at < ?", filter.To.UTC())
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+
+	var entries []model.AuditLog
+	if err := query.Order("id DESC").Limit(filter.Limit).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("database error querying audit log: %w", err)
+	}
+	return entries, nil
+}
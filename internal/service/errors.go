@@ -0,0 +1,31 @@
+package service
+
+import "errors"
+
+// ErrEmailExists is returned by Register when the email is already taken
+var ErrEmailExists = errors.New("email already exists")
+
+// ErrUnavailable is returned when a dependency is unreachable and the policy of the feature is to fail closed
+var ErrUnavailable = errors.New("service temporarily unavailable")
+
+var (
+	// ErrUserNotFound is returned when the user does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrAddressNotFound is returned when the address does not exist or belongs to another user
+	ErrAddressNotFound = errors.New("address not found or does not belong to user")
+	// ErrVersionMismatch is returned when a write was made against a version that is no longer current
+	ErrVersionMismatch = errors.New("resource has been modified, reload it and retry")
+	// ErrAddressLimitReached is returned when a user already has config.MaxAddressesPerUser addresses
+	ErrAddressLimitReached = errors.New("address limit reached")
+)
+
+// ValidationError reports invalid user input.
+// Fields maps each invalid field to the reason when the problem can be attributed to fields.
+type ValidationError struct {
+	Message string
+	Fields  map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
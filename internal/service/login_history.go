@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/blackwatch66/user-microservice/internal/audit"
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/blackwatch66/user-microservice/internal/repository"
+)
+
+// GetLoginHistory returns the most recent login attempts of a user, newest first
+func (s *userServiceImpl) GetLoginHistory(ctx context.Context, userID uint, limit int) ([]model.LoginHistory, error) {
+	history, err := s.store.Replica().LoginHistory().ListByUser(ctx, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("database error finding login history: %w", err)
+	}
+	return history, nil
+}
+
+// recordLoginHistory stores a login attempt with its client and location, userID is 0 for an unknown email.
+// A successful login from a device or country not seen in earlier successful logins emits a NewDeviceLogin event;
+// the very first login of an account never does. Failures to record are only logged so that logins are never blocked.
+func (s *userServiceImpl) recordLoginHistory(ctx context.Context, userID uint, email string, success bool, failureReason string) {
+	actor := audit.ActorFromContext(ctx)
+	location := s.locator.Lookup(actor.IP)
+	entry := model.LoginHistory{
+		UserID:        userID,
+		Email:         audit.Truncate(logger.RedactEmail(email), 255),
+		Success:       success,
+		FailureReason: failureReason,
+		IP:            actor.IP,
+		UserAgent:     audit.Truncate(actor.UserAgent, audit.MaxUserAgentLength),
+		DeviceID:      deviceID(actor.UserAgent),
+		Country:       location.Country,
+		City:          location.City,
+	}
+
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if success {
+			var err error
+			if entry.NewDevice, entry.NewCountry, err = detectNewOrigin(ctx, tx.LoginHistory(), userID, entry.DeviceID, entry.Country); err != nil {
+				return err
+			}
+		}
+		if err := tx.LoginHistory().Create(ctx, &entry); err != nil {
+			return fmt.Errorf("failed to write login history: %w", err)
+		}
+		if !entry.NewDevice && !entry.NewCountry {
+			return nil
+		}
+		return tx.Outbox().Enqueue(ctx, events.NewDeviceLogin{
+			UserID:     userID,
+			Email:      email,
+			IP:         entry.IP,
+			UserAgent:  entry.UserAgent,
+			Country:    entry.Country,
+			City:       entry.City,
+			NewDevice:  entry.NewDevice,
+			NewCountry: entry.NewCountry,
+			LoggedInAt: entry.CreatedAt,
+		})
+	})
+	if err != nil {
+		log.WarnContext(ctx, "Failed to record login history", "user_id", userID, "error", err)
+		return
+	}
+	if entry.NewDevice || entry.NewCountry {
+		log.InfoContext(ctx, "Login from new device or country", "user_id", userID, "new_device", entry.NewDevice, "new_country", entry.NewCountry, "country", entry.Country)
+	}
+}
+
+// detectNewOrigin compares a successful login with the user's earlier successful logins
+func detectNewOrigin(ctx context.Context, history repository.LoginHistoryRepository, userID uint, device, country string) (newDevice, newCountry bool, err error) {
+	previous, err := history.CountSuccessful(ctx, userID, "", "")
+	if err != nil {
+		return false, false, fmt.Errorf("database error counting logins: %w", err)
+	}
+	if previous == 0 {
+		return false, false, nil
+	}
+
+	sameDevice, err := history.CountSuccessful(ctx, userID, device, "")
+	if err != nil {
+		return false, false, fmt.Errorf("database error finding known device: %w", err)
+	}
+	newDevice = sameDevice == 0
+
+	// An unknown location is never reported as a new country
+	if country != "" {
+		sameCountry, err := history.CountSuccessful(ctx, userID, "", country)
+		if err != nil {
+			return false, false, fmt.Errorf("database error finding known country: %w", err)
+		}
+		newCountry = sameCountry == 0
+	}
+	return newDevice, newCountry, nil
+}
+
+// deviceID derives a stable device identifier from the user agent
+func deviceID(userAgent string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(userAgent)))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blackwatch66/user-microservice/internal/audit"
+	"github.com/blackwatch66/user-microservice/internal/events"
+)
+
+func TestLoginHistory(t *testing.T) {
+	svc, store := newTestService(t)
+	userID := mustRegister(t, svc, "ada@example.com")
+	laptop := audit.WithClient(context.Background(), "203.0.113.7", "Firefox")
+	phone := audit.WithClient(context.Background(), "203.0.113.8", "Safari")
+
+	attempts := []struct {
+		ctx      context.Context
+		email    string
+		password string
+	}{
+		{laptop, "nobody@example.com", testPassword},
+		{laptop, "ada@example.com", "wrong"},
+		{laptop, "ada@example.com", testPassword},
+		{phone, "Ada@Example.com", testPassword},
+	}
+	for _, a := range attempts {
+		svc.Login(a.ctx, a.email, a.password)
+	}
+
+	unknown, err := store.LoginHistory().ListByUser(context.Background(), 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unknown) != 1 || unknown[0].Email != "n***@example.com" || unknown[0].FailureReason != "unknown_email" || unknown[0].Success {
+		t.Errorf("history of unknown emails = %+v, want one redacted unknown_email failure", unknown)
+	}
+
+	history, err := svc.GetLoginHistory(context.Background(), userID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		success   bool
+		reason    string
+		newDevice bool
+	}{
+		{true, "", true}, // newest first
+		{true, "", false},
+		{false, "wrong_password", false},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("got %d history entries, want %d", len(history), len(want))
+	}
+	for i, w := range want {
+		h := history[i]
+		if h.Success != w.success || h.FailureReason != w.reason || h.NewDevice != w.newDevice {
+			t.Errorf("entry %d = %+v, want success=%v reason=%q new_device=%v", i, h, w.success, w.reason, w.newDevice)
+		}
+		if h.Email != "a***@example.com" {
+			t.Errorf("entry %d email = %q, want it redacted", i, h.Email)
+		}
+	}
+
+	var alerts int
+	for _, e := range store.OutboxEvents() {
+		if e.EventType == events.TypeNewDeviceLogin {
+			alerts++
+		}
+	}
+	if alerts != 1 {
+		t.Errorf("%d new device events, want 1 for the second device", alerts)
+	}
+}
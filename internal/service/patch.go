@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+)
+
+// fieldErrors collects validation problems by field name
+type fieldErrors map[string]string
+
+// text checks a string field that is being set: required fields must not be blank,
+// and no field may exceed the length of its column
+func (f fieldErrors) text(name string, value *string, required bool, maxLen int) {
+	switch {
+	case value == nil:
+	case required && strings.TrimSpace(*value) == "":
+		f[name] = "is required"
+	case utf8.RuneCountInString(*value) > maxLen:
+		f[name] = fmt.Sprintf("must be at most %d characters", maxLen)
+	}
+}
+
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	problems := make([]string, 0, len(names))
+	for _, name := range names {
+		problems = append(problems, name+" "+f[name])
+	}
+	return &ValidationError{Message: strings.Join(problems, "; "), Fields: f}
+}
+
+// ProfilePatch lists the profile fields to change, nil fields keep their current value
+type ProfilePatch struct {
+	FirstName *string
+	LastName  *string
+}
+
+func (p ProfilePatch) empty() bool {
+	return p.FirstName == nil && p.LastName == nil
+}
+
+func (p ProfilePatch) validate() error {
+	errs := fieldErrors{}
+	errs.text("first_name", p.FirstName, false, 50)
+	errs.text("last_name", p.LastName, false, 50)
+	return errs.err()
+}
+
+func (p ProfilePatch) apply(user *model.User) {
+	if p.FirstName != nil {
+		user.FirstName = *p.FirstName
+	}
+	if p.LastName != nil {
+		user.LastName = *p.LastName
+	}
+}
+
+// AddressPatch lists the address fields to change, nil fields keep their current value
+type AddressPatch struct {
+	Street     *string
+	City       *string
+	State      *string
+	PostalCode *string
+	Country    *string
+	IsDefault  *bool
+	Type       *string
+	Label      *string
+}
+
+// ReplaceAddress returns a patch that sets every field of an address to the value in addr,
+// an address without a type is a shipping address
+func ReplaceAddress(addr model.Address) AddressPatch {
+	if addr.Type == "" {
+		addr.Type = model.AddressTypeShipping
+	}
+	return AddressPatch{
+		Street:     &addr.Street,
+		City:       &addr.City,
+		State:      &addr.State,
+		PostalCode: &addr.PostalCode,
+		Country:    &addr.Country,
+		IsDefault:  &addr.IsDefault,
+		Type:       &addr.Type,
+		Label:      &addr.Label,
+	}
+}
+
+func (p AddressPatch) empty() bool {
+	return p == AddressPatch{}
+}
+
+func (p AddressPatch) validate() error {
+	errs := fieldErrors{}
+	errs.text("street", p.Street, true, 255)
+	errs.text("city", p.City, true, 100)
+	errs.text("state", p.State, false, 100)
+	// Whether the postal code is required depends on the country, see normalizeAddress
+	errs.text("postal_code", p.PostalCode, false, 20)
+	errs.text("country", p.Country, true, 100)
+	errs.text("label", p.Label, false, 50)
+	if p.Type != nil && !validAddressType(*p.Type) {
+		errs["type"] = "must be one of " + strings.Join(model.AddressTypes, ", ")
+	}
+	return errs.err()
+}
+
+// changesLocation reports whether the patch sets a field that the country rules apply to
+func (p AddressPatch) changesLocation() bool {
+	return p.Street != nil || p.City != nil || p.State != nil || p.PostalCode != nil || p.Country != nil
+}
+
+func (p AddressPatch) apply(addr *model.Address) {
+	if p.Street != nil {
+		addr.Street = *p.Street
+	}
+	if p.City != nil {
+		addr.City = *p.City
+	}
+	if p.State != nil {
+		addr.State = *p.State
+	}
+	if p.PostalCode != nil {
+		addr.PostalCode = *p.PostalCode
+	}
+	if p.Country != nil {
+		addr.Country = *p.Country
+	}
+	if p.IsDefault != nil {
+		addr.IsDefault = *p.IsDefault
+	}
+	if p.Type != nil {
+		addr.Type = *p.Type
+	}
+	if p.Label != nil {
+		// The label is not subject to the country rules, so it is cleaned up here rather than by normalizeAddress
+		addr.Label = strings.Join(strings.Fields(*p.Label), " ")
+	}
+}
+
+func validAddressType(addrType string) bool {
+	for _, t := range model.AddressTypes {
+		if t == addrType {
+			return true
+		}
+	}
+	return false
+}
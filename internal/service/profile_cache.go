@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/blackwatch66/user-microservice/internal/repository"
+	"golang.org/x/sync/singleflight"
+)
+
+// Names of the cached reads, used as metric label and singleflight key prefix
+const (
+	cacheProfile   = "profile"
+	cacheAddresses = "addresses"
+)
+
+// profileCache reads profiles and address lists through a repository.ProfileCache.
+// Concurrent misses for the same user share one database load, so an expiring entry of a busy
+// user does not send every waiting request to the database.
+//
+// When the cache fails, reads either load from the database (fail open) or fail with ErrUnavailable
+// (fail closed), which protects the database from the full read load while the cache is down.
+// A user whose invalidation failed is not served from the cache until a later invalidation succeeds,
+// so entries cached before an outage are not returned after the user changed during it.
+//
+// Loads that fill the cache read the primary: an entry lives for the cache TTL, so one filled from a
+// lagging replica would keep serving data from before the user's own write. Loads whose result is not
+// cached read the replica.
+type profileCache struct {
+	backend    repository.ProfileCache // nil disables caching
+	failClosed bool
+	group      singleflight.Group
+
+	mu      sync.Mutex
+	pending map[uint]struct{} // users whose cached entries may be stale
+}
+
+func newProfileCache(backend repository.ProfileCache, failClosed bool) *profileCache {
+	return &profileCache{backend: backend, failClosed: failClosed, pending: make(map[uint]struct{})}
+}
+
+func (c *profileCache) profile(ctx context.Context, store repository.Store, userID uint,
+	load func(context.Context, repository.Store) (*model.User, error)) (*model.User, error) {
+	if c.backend == nil {
+		return load(ctx, store.Replica())
+	}
+	return readThrough(ctx, c, store, cacheProfile, userID,
+		func(ctx context.Context) (*model.User, error) { return c.backend.GetProfile(ctx, userID) },
+		func(ctx context.Context, user *model.User) error { return c.backend.SetProfile(ctx, user) },
+		load)
+}
+
+func (c *profileCache) addresses(ctx context.Context, store repository.Store, userID uint,
+	load func(context.Context, repository.Store) ([]model.Address, error)) ([]model.Address, error) {
+	if c.backend == nil {
+		return load(ctx, store.Replica())
+	}
+	return readThrough(ctx, c, store, cacheAddresses, userID,
+		func(ctx context.Context) ([]model.Address, error) { return c.backend.GetAddresses(ctx, userID) },
+		func(ctx context.Context, addresses []model.Address) error {
+			return c.backend.SetAddresses(ctx, userID, addresses)
+		},
+		load)
+}
+
+// invalidate removes the cached entries of userID after it has been modified.
+// Loads already in flight are forgotten so that later reads do not wait for their stale result.
+func (c *profileCache) invalidate(ctx context.Context, userID uint) {
+	if c.backend == nil {
+		return
+	}
+	c.group.Forget(flightKey(cacheProfile, userID))
+	c.group.Forget(flightKey(cacheAddresses, userID))
+	if err := c.backend.Invalidate(ctx, userID); err != nil {
+		c.mu.Lock()
+		c.pending[userID] = struct{}{}
+		c.mu.Unlock()
+		log.WarnContext(ctx, "Failed to invalidate profile cache, bypassing it for the user until invalidation succeeds", "user_id", userID, "error", err)
+		return
+	}
+	c.mu.Lock()
+	delete(c.pending, userID)
+	c.mu.Unlock()
+}
+
+// usable reports whether the entries of userID can be read from the cache,
+// retrying a failed invalidation first
+func (c *profileCache) usable(ctx context.Context, userID uint) bool {
+	c.mu.Lock()
+	_, stale := c.pending[userID]
+	c.mu.Unlock()
+	if !stale {
+		return true
+	}
+	if err := c.backend.Invalidate(ctx, userID); err != nil {
+		return false
+	}
+	c.mu.Lock()
+	delete(c.pending, userID)
+	c.mu.Unlock()
+	return true
+}
+
+func flightKey(name string, userID uint) string {
+	return fmt.Sprintf("%s:%d", name, userID)
+}
+
+// readThrough returns the cached value or loads it from the primary of store and caches it.
+// The load is detached from the cancellation of ctx since other requests may be waiting for it;
+// a cancelled caller stops waiting while the load completes for the others.
+func readThrough[T any](ctx context.Context, c *profileCache, store repository.Store, name string, userID uint,
+	get func(context.Context) (T, error), set func(context.Context, T) error, load func(context.Context, repository.Store) (T, error)) (T, error) {
+	var zero T
+	if !c.usable(ctx, userID) {
+		metrics.CacheRequestsTotal.WithLabelValues(name, metrics.CacheError).Inc()
+		if c.failClosed {
+			return zero, fmt.Errorf("%w: profile cache cannot be invalidated", ErrUnavailable)
+		}
+		return load(ctx, store.Replica())
+	}
+
+	value, err := get(ctx)
+	if err == nil {
+		metrics.CacheRequestsTotal.WithLabelValues(name, metrics.CacheHit).Inc()
+		return value, nil
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		metrics.CacheRequestsTotal.WithLabelValues(name, metrics.CacheMiss).Inc()
+	} else {
+		metrics.CacheRequestsTotal.WithLabelValues(name, metrics.CacheError).Inc()
+		if c.failClosed {
+			return zero, fmt.Errorf("%w: profile cache: %v", ErrUnavailable, err)
+		}
+		// Not a warning: while Redis is down every read ends up here, the outage itself is logged by the client
+		log.DebugContext(ctx, "Profile cache read failed, loading from the database", "cache", name, "user_id", userID, "error", err)
+	}
+
+	result := c.group.DoChan(flightKey(name, userID), func() (interface{}, error) {
+		loadCtx := context.WithoutCancel(ctx)
+		value, err := load(loadCtx, store)
+		if err != nil {
+			return nil, err
+		}
+		if err := set(loadCtx, value); err != nil {
+			log.DebugContext(loadCtx, "Failed to fill profile cache", "cache", name, "user_id", userID, "error", err)
+		}
+		return value, nil
+	})
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		return res.Val.(T), nil
+	}
+}
+
+// cacheInvalidator is an events.EventPublisher that drops the cached entries of the user an event is about.
+// It runs in the outbox relay after the change has committed, which removes entries that a concurrent
+// read may have filled with data from before the commit.
+// Failures are only logged: retrying would hold back the event for every other publisher,
+// and the entries expire with their TTL anyway.
+type cacheInvalidator struct {
+	cache repository.ProfileCache
+}
+
+// NewCacheInvalidator creates an events.EventPublisher that invalidates the cached entries of users in cache
+func NewCacheInvalidator(cache repository.ProfileCache) events.EventPublisher {
+	return &cacheInvalidator{cache: cache}
+}
+
+func (i *cacheInvalidator) Publish(ctx context.Context, envelope *events.Envelope) error {
+	if envelope.AggregateType != events.AggregateUser {
+		return nil
+	}
+	userID, err := strconv.ParseUint(envelope.AggregateID, 10, 64)
+	if err != nil {
+		log.WarnContext(ctx, "Ignoring event with invalid user aggregate ID", "event_id", envelope.ID, "aggregate_id", envelope.AggregateID)
+		return nil
+	}
+	if err := i.cache.Invalidate(ctx, uint(userID)); err != nil {
+		log.WarnContext(ctx, "Failed to invalidate profile cache for event", "event_id", envelope.ID, "user_id", userID, "error", err)
+	}
+	return nil
+}
+
+func (i *cacheInvalidator) Close() error {
+	return nil
+}
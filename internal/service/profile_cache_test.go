@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/repository"
+)
+
+// laggingReplicaStore is a Store whose replica has not received any write yet
+type laggingReplicaStore struct {
+	repository.Store
+	replica repository.Store
+}
+
+func (s laggingReplicaStore) Replica() repository.Store { return s.replica }
+
+func TestProfileCacheFillsFromPrimary(t *testing.T) {
+	tests := []struct {
+		name          string
+		cache         repository.ProfileCache
+		wantAddresses int
+	}{
+		{"cached reads load from the primary", repository.NewMemoryProfileCache(time.Minute), 1},
+		{"uncached reads use the replica", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, store := newTestService(t)
+			userID := mustRegister(t, svc, "ada@example.com")
+			mustAddAddress(t, svc, userID, testAddress())
+			svc.store = laggingReplicaStore{Store: store, replica: repository.NewMemoryStore()}
+			svc.cache = newProfileCache(tt.cache, false)
+
+			for i := 0; i < 2; i++ {
+				addresses, err := svc.GetUserAddresses(ctx, userID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(addresses) != tt.wantAddresses {
+					t.Errorf("read %d: %d addresses, want %d", i, len(addresses), tt.wantAddresses)
+				}
+			}
+			if tt.cache == nil {
+				return
+			}
+			cached, err := tt.cache.GetAddresses(ctx, userID)
+			if err != nil || len(cached) != 1 {
+				t.Errorf("cached addresses = %v, %v, want the address from the primary", cached, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/config"
+	"github.com/blackwatch66/user-microservice/internal/geoip"
+	"github.com/blackwatch66/user-microservice/internal/repository"
+)
+
+// newTestService returns a service backed by in-memory stores and the store it writes to
+func newTestService(t *testing.T) (*userServiceImpl, *repository.MemoryStore) {
+	t.Helper()
+	locator, err := geoip.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := repository.NewMemoryStore()
+	cfg := &config.Config{
+		JWTSecret:           "test-secret",
+		JWTExpiry:           15 * time.Minute,
+		MaxAddressesPerUser: 20,
+	}
+	svc := NewUserService(store, repository.NewMemorySessionStore(), repository.NewMemoryProfileCache(time.Minute), cfg, locator)
+	return svc.(*userServiceImpl), store
+}
+
+// mustRegister registers a user with the test password
+func mustRegister(t *testing.T, svc UserService, email string) uint {
+	t.Helper()
+	user, err := svc.Register(context.Background(), email, testPassword)
+	if err != nil {
+		t.Fatalf("Register(%s) = %v", email, err)
+	}
+	return user.ID
+}
+
+const testPassword = "correct horse battery"
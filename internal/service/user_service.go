@@ -4,49 +4,78 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"strings"
 	"time"
 
 	"github.com/blackwatch66/user-microservice/config"
+	"github.com/blackwatch66/user-microservice/internal/audit"
 	"github.com/blackwatch66/user-microservice/internal/auth"
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/geoip"
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/blackwatch66/user-microservice/internal/metrics"
 	"github.com/blackwatch66/user-microservice/internal/model"
-	redisClient "github.com/blackwatch66/user-microservice/internal/redis"
-	"github.com/go-redis/redis/v8"
-	"gorm.io/gorm"
+	"github.com/blackwatch66/user-microservice/internal/repository"
 )
 
+var log = logger.For("service")
+
 // UserService defines the user service interface
 type UserService interface {
 	Register(ctx context.Context, email, password string) (*model.User, error)
 	Login(ctx context.Context, email, password string) (string, error) // Returns JWT
 	GetUserProfile(ctx context.Context, userID uint) (*model.User, error)
-	UpdateUserProfile(ctx context.Context, userID uint, firstName, lastName string) (*model.User, error)
+	// UpdateUserProfile, UpdateUserAddress and DeleteUserAddress only apply the change while the record is
+	// at ifVersion and return ErrVersionMismatch otherwise, an ifVersion of 0 applies it to the current version
+	UpdateUserProfile(ctx context.Context, userID uint, ifVersion uint64, firstName, lastName string) (*model.User, error)
+	// PatchUserProfile changes only the fields set in patch
+	PatchUserProfile(ctx context.Context, userID uint, ifVersion uint64, patch ProfilePatch) (*model.User, error)
 	GetUserAddresses(ctx context.Context, userID uint) ([]model.Address, error)
+	GetUserAddress(ctx context.Context, userID, addrID uint) (*model.Address, error)
 	AddUserAddress(ctx context.Context, userID uint, addr model.Address) (*model.Address, error)
-	UpdateUserAddress(ctx context.Context, userID, addrID uint, addr model.Address) (*model.Address, error)
-	DeleteUserAddress(ctx context.Context, userID, addrID uint) error
+	UpdateUserAddress(ctx context.Context, userID, addrID uint, ifVersion uint64, addr model.Address) (*model.Address, error)
+	// PatchUserAddress changes only the fields set in patch
+	PatchUserAddress(ctx context.Context, userID, addrID uint, ifVersion uint64, patch AddressPatch) (*model.Address, error)
+	// SetDefaultAddress makes the address the default of its type
+	SetDefaultAddress(ctx context.Context, userID, addrID uint, ifVersion uint64) (*model.Address, error)
+	// MergeUserAddresses merges duplicate addresses into the address addrID and returns it with the IDs of the merged addresses
+	MergeUserAddresses(ctx context.Context, userID, addrID uint, ifVersion uint64, duplicateIDs []uint) (*model.Address, []uint, error)
+	DeleteUserAddress(ctx context.Context, userID, addrID uint, ifVersion uint64) error
     ValidateToken(ctx context.Context, tokenString string) (*auth.Claims, error)
+	GetLoginHistory(ctx context.Context, userID uint, limit int) ([]model.LoginHistory, error)
 }
 
 // userServiceImpl implements the UserService interface
 type userServiceImpl struct {
-	db  *gorm.DB
-	rdb *redis.Client
-	cfg *config.Config
+	store    repository.Store
+	sessions repository.SessionStore
+	cache    *profileCache
+	cfg      *config.Config
+	locator  geoip.Locator
 }
 
-// NewUserService creates a new UserService instance
-func NewUserService(db *gorm.DB, rdb *redis.Client, cfg *config.Config) UserService {
-	return &userServiceImpl{db: db, rdb: rdb, cfg: cfg}
+// NewUserService creates a new UserService instance.
+// Profiles and address lists are read through cache, a nil cache disables caching.
+// cfg.SessionsRedisPolicy and cfg.CacheRedisPolicy decide what happens when sessions or cache fail.
+func NewUserService(store repository.Store, sessions repository.SessionStore, cache repository.ProfileCache, cfg *config.Config, locator geoip.Locator) UserService {
+	return &userServiceImpl{
+		store:    store,
+		sessions: sessions,
+		cache:    newProfileCache(cache, cfg.CacheRedisPolicy == config.PolicyFailClosed),
+		cfg:      cfg,
+		locator:  locator,
+	}
 }
 
 // Register handles user registration logic
 func (s *userServiceImpl) Register(ctx context.Context, email, password string) (*model.User, error) {
-	// Check if email already exists
-	var existingUser model.User
-	if err := s.db.WithContext(ctx).Where("email = ?", email).First(&existingUser).Error; err == nil {
-		return nil, errors.New("email already exists")
-	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+	email = normalizeEmail(email)
+
+	// Check if email already exists. This only gives a fast answer, concurrent
+	// registrations are caught by the unique index when the user is created.
+	if _, err := s.store.Users().FindByEmail(ctx, email); err == nil {
+		return nil, ErrEmailExists
+	} else if !errors.Is(err, repository.ErrNotFound) {
 		return nil, fmt.Errorf("database error checking email: %w", err)
 	}
 
@@ -62,167 +91,547 @@ func (s *userServiceImpl) Register(ctx context.Context, email, password string)
 		PasswordHash: hashedPassword,
 	}
 
-	if err := s.db.WithContext(ctx).Create(&user).Error; err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	// Create the user and its UserRegistered event atomically
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Users().Create(ctx, &user); err != nil {
+			if errors.Is(err, repository.ErrDuplicate) {
+				return ErrEmailExists
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		if err := tx.Audit().Record(ctx, audit.Entry{
+			Action:       audit.ActionRegister,
+			TargetUserID: user.ID,
+			Changes:      audit.Diff(nil, profileSnapshot(&user)),
+		}); err != nil {
+			return err
+		}
+		return tx.Outbox().Enqueue(ctx, events.UserRegistered{
+			UserID:       user.ID,
+			Email:        user.Email,
+			RegisteredAt: user.CreatedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// TODO: Emit UserCreated event (optional)
-	log.Printf("User registered: ID=%d, Email=%s\n", user.ID, user.Email)
+	log.InfoContext(ctx, "User registered", "user_id", user.ID, "email", user.Email)
 
 	return &user, nil
 }
 
 // Login handles user login logic
 func (s *userServiceImpl) Login(ctx context.Context, email, password string) (string, error) {
-	var user model.User
-	if err := s.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+	email = normalizeEmail(email)
+	user, err := s.store.Users().FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			metrics.ObserveLogin(metrics.LoginInvalidCredentials)
+			s.recordLogin(ctx, 0, email, audit.OutcomeFailure, "unknown_email")
+			s.recordLoginHistory(ctx, 0, email, false, "unknown_email")
 			return "", errors.New("invalid email or password")
 		}
+		metrics.ObserveLogin(metrics.LoginError)
 		return "", fmt.Errorf("database error finding user: %w", err)
 	}
 
 	// Check password
 	if !auth.CheckPasswordHash(password, user.PasswordHash) {
+		metrics.ObserveLogin(metrics.LoginInvalidCredentials)
+		s.recordLogin(ctx, user.ID, email, audit.OutcomeFailure, "wrong_password")
+		s.recordLoginHistory(ctx, user.ID, user.Email, false, "wrong_password")
 		return "", errors.New("invalid email or password")
 	}
 
 	// Generate JWT
 	tokenString, err := auth.GenerateJWT(user.ID, user.Email, s.cfg.JWTSecret, s.cfg.JWTExpiry)
 	if err != nil {
+		metrics.ObserveLogin(metrics.LoginError)
 		return "", fmt.Errorf("failed to generate JWT: %w", err)
 	}
 
-	// Store JWT identifier in Redis (per document requirements)
+	// Store JWT identifier in the session store (per document requirements)
     // Simple example using UserID as key, more complex strategies might be needed
     // E.g. storing JTI (JWT ID) or the token itself with expiry time matching JWT
-	err = s.rdb.Set(redisClient.Ctx, fmt.Sprintf("jwt:%d", user.ID), tokenString, s.cfg.JWTExpiry).Err()
-    if err != nil {
-        // Even if Redis fails, we may allow login success but log the error
-        log.Printf("WARN: Failed to store JWT identifier in Redis for user %d: %v\n", user.ID, err)
-    }
+	err = s.sessions.Save(ctx, user.ID, tokenString, s.cfg.JWTExpiry)
+	if err != nil {
+		if s.cfg.SessionsRedisPolicy == config.PolicyFailClosed {
+			metrics.ObserveLogin(metrics.LoginError)
+			log.ErrorContext(ctx, "Failed to store JWT identifier in session store, rejecting login", "user_id", user.ID, "error", err)
+			return "", fmt.Errorf("%w: session store: %v", ErrUnavailable, err)
+		}
+		// With the fail_open policy the login succeeds, the token just cannot be looked up in the session store
+		log.WarnContext(ctx, "Failed to store JWT identifier in session store", "user_id", user.ID, "error", err)
+	}
+
+	metrics.ObserveLogin(metrics.LoginSuccess)
+	s.recordLogin(ctx, user.ID, email, audit.OutcomeSuccess, "")
+	s.recordLoginHistory(ctx, user.ID, user.Email, true, "")
 
 	return tokenString, nil
 }
 
 // GetUserProfile retrieves user profile
 func (s *userServiceImpl) GetUserProfile(ctx context.Context, userID uint) (*model.User, error) {
-	var user model.User
-	// Preload Addresses to get associated address information
-	if err := s.db.WithContext(ctx).Preload("Addresses").First(&user, userID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+	return s.cache.profile(ctx, s.store, userID, func(ctx context.Context, reads repository.Store) (*model.User, error) {
+		return s.loadUserProfile(ctx, reads, userID)
+	})
+}
+
+// loadUserProfile reads a profile with its addresses from reads, the primary or a replica
+func (s *userServiceImpl) loadUserProfile(ctx context.Context, reads repository.Store, userID uint) (*model.User, error) {
+	// A user missing on a replica may have just registered, so ask the primary
+	user, err := reads.Users().FindByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) && reads != s.store {
+		reads = s.store
+		user, err = reads.Users().FindByID(ctx, userID)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("database error finding user: %w", err)
 	}
+	// Load addresses to get associated address information
+	if user.Addresses, err = reads.Addresses().ListByUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("database error finding addresses: %w", err)
+	}
 	// Clean sensitive information
     user.PasswordHash = ""
-	return &user, nil
+	return user, nil
 }
 
 // UpdateUserProfile updates user profile
-func (s *userServiceImpl) UpdateUserProfile(ctx context.Context, userID uint, firstName, lastName string) (*model.User, error) {
-	var user model.User
-	if err := s.db.WithContext(ctx).First(&user, userID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+func (s *userServiceImpl) UpdateUserProfile(ctx context.Context, userID uint, ifVersion uint64, firstName, lastName string) (*model.User, error) {
+	return s.PatchUserProfile(ctx, userID, ifVersion, ProfilePatch{FirstName: &firstName, LastName: &lastName})
+}
+
+// PatchUserProfile updates the profile fields set in patch, an empty patch returns the profile unchanged
+func (s *userServiceImpl) PatchUserProfile(ctx context.Context, userID uint, ifVersion uint64, patch ProfilePatch) (*model.User, error) {
+	if err := patch.validate(); err != nil {
+		return nil, err
+	}
+	user, err := s.store.Users().FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("database error finding user: %w", err)
 	}
+	if ifVersion != 0 && user.Version != ifVersion {
+		return nil, ErrVersionMismatch
+	}
+	if patch.empty() {
+		user.PasswordHash = ""
+		return user, nil
+	}
 
-	user.FirstName = firstName
-	user.LastName = lastName
+	before := profileSnapshot(user)
+	patch.apply(user)
 
-	if err := s.db.WithContext(ctx).Save(&user).Error; err != nil {
-		return nil, fmt.Errorf("failed to update user profile: %w", err)
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Users().Update(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrVersionConflict) {
+				return ErrVersionMismatch
+			}
+			return fmt.Errorf("failed to update user profile: %w", err)
+		}
+		if err := tx.Audit().Record(ctx, audit.Entry{
+			Action:       audit.ActionProfileUpdate,
+			TargetUserID: user.ID,
+			Changes:      audit.Diff(before, profileSnapshot(user)),
+		}); err != nil {
+			return err
+		}
+		return tx.Outbox().Enqueue(ctx, events.ProfileUpdated{
+			UserID:    user.ID,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			UpdatedAt: user.UpdatedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.cache.invalidate(ctx, userID)
     // Clean sensitive information
     user.PasswordHash = ""
-	return &user, nil
+	return user, nil
 }
 
 // GetUserAddresses gets user address list
 func (s *userServiceImpl) GetUserAddresses(ctx context.Context, userID uint) ([]model.Address, error) {
-	var addresses []model.Address
-	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Find(&addresses).Error; err != nil {
-		return nil, fmt.Errorf("database error finding addresses: %w", err)
+	return s.cache.addresses(ctx, s.store, userID, func(ctx context.Context, reads repository.Store) ([]model.Address, error) {
+		addresses, err := reads.Addresses().ListByUser(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("database error finding addresses: %w", err)
+		}
+		return addresses, nil
+	})
+}
+
+// GetUserAddress gets one address of the user
+func (s *userServiceImpl) GetUserAddress(ctx context.Context, userID, addrID uint) (*model.Address, error) {
+	addr, err := s.store.Addresses().FindByUser(ctx, userID, addrID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrAddressNotFound
+		}
+		return nil, fmt.Errorf("database error finding address: %w", err)
 	}
-	return addresses, nil
+	return addr, nil
 }
 
-// AddUserAddress adds a user address
+// AddUserAddress adds a user address. An address that duplicates a stored address of the same type is not added,
+// a *DuplicateAddressError points to the stored one.
 func (s *userServiceImpl) AddUserAddress(ctx context.Context, userID uint, addr model.Address) (*model.Address, error) {
+	if err := ReplaceAddress(addr).validate(); err != nil {
+		return nil, err
+	}
+	if err := normalizeAddress(&addr); err != nil {
+		return nil, err
+	}
 	// Ensure address belongs to the user
 	addr.UserID = userID
 	// Clean possible client-provided ID and timestamps
     addr.ID = 0
     addr.CreatedAt = time.Time{}
     addr.UpdatedAt = time.Time{}
+	if addr.Type == "" {
+		addr.Type = model.AddressTypeShipping
+	}
 
-	if err := s.db.WithContext(ctx).Create(&addr).Error; err != nil {
-		return nil, fmt.Errorf("failed to add address: %w", err)
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		// Concurrent adds for the same user wait here, so the duplicate and limit checks see each other's addresses
+		if err := tx.Users().Lock(ctx, userID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("database error locking user: %w", err)
+		}
+		addresses, err := tx.Addresses().ListByUser(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("database error finding addresses: %w", err)
+		}
+		siblings := ofType(addresses, addr.Type, 0)
+		if duplicate := findDuplicate(&addr, siblings); duplicate != nil {
+			return duplicate
+		}
+		if limit := s.cfg.MaxAddressesPerUser; limit > 0 && len(addresses) >= limit {
+			return fmt.Errorf("%w: at most %d addresses per user", ErrAddressLimitReached, limit)
+		}
+
+		// The first address of a type becomes its default, a new default replaces the previous one
+		if !hasDefault(siblings) {
+			addr.IsDefault = true
+		} else if addr.IsDefault {
+			if err := clearDefault(ctx, tx, siblings); err != nil {
+				return err
+			}
+		}
+		if err := tx.Addresses().Create(ctx, &addr); err != nil {
+			return addressWriteError(err, "add address")
+		}
+		if err := tx.Audit().Record(ctx, audit.Entry{
+			Action:       audit.ActionAddressAdd,
+			TargetUserID: userID,
+			Changes:      audit.Diff(nil, addressSnapshot(&addr)),
+			Details:      map[string]interface{}{"address_id": addr.ID},
+		}); err != nil {
+			return err
+		}
+		return tx.Outbox().Enqueue(ctx, events.AddressAdded{UserID: userID, Address: events.NewAddress(addr)})
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.cache.invalidate(ctx, userID)
 	return &addr, nil
 }
 
-// UpdateUserAddress updates a user address
-func (s *userServiceImpl) UpdateUserAddress(ctx context.Context, userID, addrID uint, updatedAddr model.Address) (*model.Address, error) {
-	var existingAddr model.Address
-	if err := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", addrID, userID).First(&existingAddr).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("address not found or does not belong to user")
+// UpdateUserAddress replaces all fields of a user address
+func (s *userServiceImpl) UpdateUserAddress(ctx context.Context, userID, addrID uint, ifVersion uint64, updatedAddr model.Address) (*model.Address, error) {
+	return s.PatchUserAddress(ctx, userID, addrID, ifVersion, ReplaceAddress(updatedAddr))
+}
+
+// PatchUserAddress updates the address fields set in patch, an empty patch returns the address unchanged
+func (s *userServiceImpl) PatchUserAddress(ctx context.Context, userID, addrID uint, ifVersion uint64, patch AddressPatch) (*model.Address, error) {
+	if err := patch.validate(); err != nil {
+		return nil, err
+	}
+	existingAddr, err := s.store.Addresses().FindByUser(ctx, userID, addrID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrAddressNotFound
 		}
 		return nil, fmt.Errorf("database error finding address: %w", err)
 	}
+	if ifVersion != 0 && existingAddr.Version != ifVersion {
+		return nil, ErrVersionMismatch
+	}
+	if patch.empty() {
+		return existingAddr, nil
+	}
 
 	// Update fields
-	existingAddr.Street = updatedAddr.Street
-	existingAddr.City = updatedAddr.City
-	existingAddr.State = updatedAddr.State
-	existingAddr.PostalCode = updatedAddr.PostalCode
-	existingAddr.Country = updatedAddr.Country
-	existingAddr.IsDefault = updatedAddr.IsDefault
+	previous := *existingAddr
+	before := addressSnapshot(existingAddr)
+	patch.apply(existingAddr)
+	if patch.changesLocation() {
+		if err := normalizeAddress(existingAddr); err != nil {
+			return nil, err
+		}
+	}
+	typeChanged := existingAddr.Type != previous.Type
+
+	unchanged := false
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		// Keep exactly one default among the addresses of the new type
+		siblings, err := addressesOfType(ctx, tx, userID, existingAddr.Type, addrID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !existingAddr.IsDefault && previous.IsDefault && !typeChanged && len(siblings) > 0:
+			return &ValidationError{Message: "is_default " + errDefaultRequired, Fields: map[string]string{"is_default": errDefaultRequired}}
+		case !hasDefault(siblings):
+			existingAddr.IsDefault = true
+		case typeChanged && patch.IsDefault == nil:
+			existingAddr.IsDefault = false
+		}
+		changes := audit.Diff(before, addressSnapshot(existingAddr))
+		if len(changes) == 0 {
+			unchanged = true
+			return nil
+		}
 
-	if err := s.db.WithContext(ctx).Save(&existingAddr).Error; err != nil {
-		return nil, fmt.Errorf("failed to update address: %w", err)
+		if existingAddr.IsDefault {
+			if err := clearDefault(ctx, tx, siblings); err != nil {
+				return err
+			}
+		}
+		if err := tx.Addresses().Update(ctx, existingAddr); err != nil {
+			return addressWriteError(err, "update address")
+		}
+		// An address that leaves its type hands the default over to the remaining addresses of that type
+		if typeChanged && previous.IsDefault {
+			remaining, err := addressesOfType(ctx, tx, userID, previous.Type, addrID)
+			if err != nil {
+				return err
+			}
+			if err := promoteDefault(ctx, tx, remaining); err != nil {
+				return err
+			}
+		}
+		if err := tx.Audit().Record(ctx, audit.Entry{
+			Action:       audit.ActionAddressUpdate,
+			TargetUserID: userID,
+			Changes:      changes,
+			Details:      map[string]interface{}{"address_id": addrID},
+		}); err != nil {
+			return err
+		}
+		return tx.Outbox().Enqueue(ctx, events.AddressUpdated{UserID: userID, Address: events.NewAddress(*existingAddr)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unchanged {
+		return &previous, nil
 	}
-	return &existingAddr, nil
+	s.cache.invalidate(ctx, userID)
+	return existingAddr, nil
+}
+
+// SetDefaultAddress makes the address the default of its type, the previous default stops being one
+func (s *userServiceImpl) SetDefaultAddress(ctx context.Context, userID, addrID uint, ifVersion uint64) (*model.Address, error) {
+	isDefault := true
+	return s.PatchUserAddress(ctx, userID, addrID, ifVersion, AddressPatch{IsDefault: &isDefault})
+}
+
+// MergeUserAddresses deletes duplicates of the address addrID: the addresses in duplicateIDs, which must all duplicate it,
+// or every stored duplicate when duplicateIDs is empty. The address keeps its values, it becomes the default if a merged
+// address was and takes the label of the first merged address if it has none.
+func (s *userServiceImpl) MergeUserAddresses(ctx context.Context, userID, addrID uint, ifVersion uint64, duplicateIDs []uint) (*model.Address, []uint, error) {
+	var target *model.Address
+	var mergedIDs []uint
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		addresses, err := tx.Addresses().ListByUser(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("database error finding addresses: %w", err)
+		}
+		for i := range addresses {
+			if addresses[i].ID == addrID {
+				target = &addresses[i]
+			}
+		}
+		if target == nil {
+			return ErrAddressNotFound
+		}
+		if ifVersion != 0 && target.Version != ifVersion {
+			return ErrVersionMismatch
+		}
+		duplicates, err := selectDuplicates(target, addresses, duplicateIDs)
+		if err != nil || len(duplicates) == 0 {
+			return err
+		}
+
+		before := addressSnapshot(target)
+		for _, duplicate := range duplicates {
+			// Duplicates are deleted before the address takes over their default, see idx_address_user_default
+			if err := tx.Addresses().Delete(ctx, duplicate.ID, duplicate.Version); err != nil {
+				return addressWriteError(err, "delete duplicate address")
+			}
+			if err := tx.Outbox().Enqueue(ctx, events.AddressDeleted{UserID: userID, AddressID: duplicate.ID}); err != nil {
+				return err
+			}
+			target.IsDefault = target.IsDefault || duplicate.IsDefault
+			if target.Label == "" {
+				target.Label = duplicate.Label
+			}
+			mergedIDs = append(mergedIDs, duplicate.ID)
+		}
+		changes := audit.Diff(before, addressSnapshot(target))
+		if len(changes) > 0 {
+			if err := tx.Addresses().Update(ctx, target); err != nil {
+				return addressWriteError(err, "update merged address")
+			}
+			if err := tx.Outbox().Enqueue(ctx, events.AddressUpdated{UserID: userID, Address: events.NewAddress(*target)}); err != nil {
+				return err
+			}
+		}
+		if err := tx.Audit().Record(ctx, audit.Entry{
+			Action:       audit.ActionAddressMerge,
+			TargetUserID: userID,
+			Changes:      changes,
+			Details:      map[string]interface{}{"address_id": addrID, "merged_address_ids": mergedIDs},
+		}); err != nil {
+			return err
+		}
+		return tx.Outbox().Enqueue(ctx, events.AddressMerged{UserID: userID, Address: events.NewAddress(*target), MergedAddressIDs: mergedIDs})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(mergedIDs) > 0 {
+		s.cache.invalidate(ctx, userID)
+	}
+	return target, mergedIDs, nil
 }
 
 // DeleteUserAddress deletes a user address
-func (s *userServiceImpl) DeleteUserAddress(ctx context.Context, userID, addrID uint) error {
+func (s *userServiceImpl) DeleteUserAddress(ctx context.Context, userID, addrID uint, ifVersion uint64) error {
     // First check if the address exists and belongs to the user
-    var addr model.Address
-    if err := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", addrID, userID).First(&addr).Error; err != nil {
-        if errors.Is(err, gorm.ErrRecordNotFound) {
+    addr, err := s.store.Addresses().FindByUser(ctx, userID, addrID)
+    if err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
             // Address doesn't exist or doesn't belong to user, can be treated as delete success or return specific error
-            return errors.New("address not found or does not belong to user")
+            return ErrAddressNotFound
         }
         return fmt.Errorf("database error finding address before delete: %w", err)
     }
+	if ifVersion != 0 && addr.Version != ifVersion {
+		return ErrVersionMismatch
+	}
 
 	// Execute delete
-	if err := s.db.WithContext(ctx).Delete(&model.Address{}, addrID).Error; err != nil {
-		return fmt.Errorf("failed to delete address: %w", err)
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Addresses().Delete(ctx, addrID, addr.Version); err != nil {
+			return addressWriteError(err, "delete address")
+		}
+		// Deleting the default address makes the most recent remaining address of its type the default
+		if addr.IsDefault {
+			remaining, err := addressesOfType(ctx, tx, userID, addr.Type, addrID)
+			if err != nil {
+				return err
+			}
+			if err := promoteDefault(ctx, tx, remaining); err != nil {
+				return err
+			}
+		}
+		if err := tx.Audit().Record(ctx, audit.Entry{
+			Action:       audit.ActionAddressDelete,
+			TargetUserID: userID,
+			Changes:      audit.Diff(addressSnapshot(addr), nil),
+			Details:      map[string]interface{}{"address_id": addrID},
+		}); err != nil {
+			return err
+		}
+		return tx.Outbox().Enqueue(ctx, events.AddressDeleted{UserID: userID, AddressID: addrID})
+	})
+	if err != nil {
+		return err
 	}
+	s.cache.invalidate(ctx, userID)
 	return nil
 }
 
-// ValidateToken validates JWT Token (for gRPC use)
+// ValidateToken validates a JWT and checks that it was issued after the latest revocation of its user's tokens.
+// A user without a revocation record has no revoked tokens, so losing the session store's data logs nobody out.
+// cfg.RevocationRedisPolicy decides whether tokens are accepted while the session store fails.
 func (s *userServiceImpl) ValidateToken(ctx context.Context, tokenString string) (*auth.Claims, error) {
-    claims, err := auth.ValidateJWT(tokenString, s.cfg.JWTSecret)
-    if err != nil {
-        return nil, fmt.Errorf("token validation failed: %w", err)
-    }
+	claims, err := auth.ValidateJWT(tokenString, s.cfg.JWTSecret)
+	if err != nil {
+		return nil, fmt.Errorf("token validation failed: %w", err)
+	}
 
-    // Optional: Check if token identifier exists in Redis (for quick revocation)
-    // _, err = s.rdb.Get(redisClient.Ctx, fmt.Sprintf("jwt:%d", claims.UserID)).Result()
-    // if err == redis.Nil {
-    //     return nil, errors.New("token has been revoked or expired from cache")
-    // } else if err != nil {
-    //     log.Printf("WARN: Redis error during token validation for user %d: %v\n", claims.UserID, err)
-    //     // Depending on policy, may reject token due to Redis error
-    // }
-
-    return claims, nil
-} 
\ No newline at end of file
+	revokedBefore, err := s.sessions.RevokedBefore(ctx, claims.UserID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+	case err != nil && s.cfg.RevocationRedisPolicy == config.PolicyFailClosed:
+		log.ErrorContext(ctx, "Failed to check token revocation, rejecting token", "user_id", claims.UserID, "error", err)
+		return nil, fmt.Errorf("%w: session store: %v", ErrUnavailable, err)
+	case err != nil:
+		// With the fail_open policy a validly signed token is accepted while revocation cannot be checked
+		log.WarnContext(ctx, "Failed to check token revocation, accepting token", "user_id", claims.UserID, "error", err)
+	case claims.IssuedAt == nil || !claims.IssuedAt.After(revokedBefore):
+		return nil, auth.ErrTokenRevoked
+	}
+	return claims, nil
+} 
+
+// recordLogin writes the outcome of a login attempt to the audit log.
+// Failures to write are only logged so that the audit log never blocks logins.
+func (s *userServiceImpl) recordLogin(ctx context.Context, userID uint, email, outcome, reason string) {
+	details := map[string]interface{}{"email": email}
+	if reason != "" {
+		details["reason"] = reason
+	}
+	if err := s.store.Audit().Record(ctx, audit.Entry{
+		Action:       audit.ActionLogin,
+		Outcome:      outcome,
+		TargetUserID: userID,
+		Details:      details,
+	}); err != nil {
+		log.WarnContext(ctx, "Failed to record login in audit log", "user_id", userID, "error", err)
+	}
+}
+
+// profileSnapshot returns the audited profile fields of user
+func profileSnapshot(user *model.User) map[string]interface{} {
+	return map[string]interface{}{
+		"email":      user.Email,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+	}
+}
+
+// addressSnapshot returns the audited fields of addr
+func addressSnapshot(addr *model.Address) map[string]interface{} {
+	return map[string]interface{}{
+		"street":      addr.Street,
+		"city":        addr.City,
+		"state":       addr.State,
+		"postal_code": addr.PostalCode,
+		"country":     addr.Country,
+		"is_default":  addr.IsDefault,
+		"type":        addr.Type,
+		"label":       addr.Label,
+	}
+}
+
+// normalizeEmail trims and lower-cases an email address, emails are stored and looked up in this form
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
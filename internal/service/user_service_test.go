@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/config"
+	"github.com/blackwatch66/user-microservice/internal/auth"
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/blackwatch66/user-microservice/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		wantErr error
+	}{
+		{"new email", "grace@example.com", nil},
+		{"same email", "ada@example.com", ErrEmailExists},
+		{"email differing in case and whitespace", "  ADA@example.com ", ErrEmailExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(t)
+			mustRegister(t, svc, "ada@example.com")
+			user, err := svc.Register(context.Background(), tt.email, testPassword)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Register() = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && user.PasswordHash == testPassword {
+				t.Error("password stored in plain text")
+			}
+		})
+	}
+}
+
+func TestVersionMismatch(t *testing.T) {
+	ctx := context.Background()
+	street := "1 Infinite Loop"
+
+	tests := []struct {
+		name string
+		// write changes the profile or address of userID with the given expected version
+		write func(svc UserService, userID, addrID uint, version uint64) error
+		// current returns the version write has to match
+		current func(user *model.User, addr *model.Address) uint64
+	}{
+		{"update profile", func(svc UserService, userID, _ uint, version uint64) error {
+			_, err := svc.UpdateUserProfile(ctx, userID, version, "Ada", "Lovelace")
+			return err
+		}, func(user *model.User, _ *model.Address) uint64 { return user.Version }},
+		{"patch address", func(svc UserService, userID, addrID uint, version uint64) error {
+			_, err := svc.PatchUserAddress(ctx, userID, addrID, version, AddressPatch{Street: &street})
+			return err
+		}, func(_ *model.User, addr *model.Address) uint64 { return addr.Version }},
+		{"set default address", func(svc UserService, userID, addrID uint, version uint64) error {
+			_, err := svc.SetDefaultAddress(ctx, userID, addrID, version)
+			return err
+		}, func(_ *model.User, addr *model.Address) uint64 { return addr.Version }},
+		{"delete address", func(svc UserService, userID, addrID uint, version uint64) error {
+			return svc.DeleteUserAddress(ctx, userID, addrID, version)
+		}, func(_ *model.User, addr *model.Address) uint64 { return addr.Version }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, store := newTestService(t)
+			userID := mustRegister(t, svc, "ada@example.com")
+			addr := mustAddAddress(t, svc, userID, testAddress())
+			user, err := svc.GetUserProfile(ctx, userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			current := tt.current(user, addr)
+			events := len(store.OutboxEvents())
+
+			if err := tt.write(svc, userID, addr.ID, current+1); !errors.Is(err, ErrVersionMismatch) {
+				t.Fatalf("write with a stale version = %v, want ErrVersionMismatch", err)
+			}
+			if got := len(store.OutboxEvents()); got != events {
+				t.Errorf("rejected write emitted %d events", got-events)
+			}
+			if err := tt.write(svc, userID, addr.ID, current); err != nil {
+				t.Fatalf("write with the current version = %v", err)
+			}
+		})
+	}
+}
+
+// failingOutboxStore is a Store whose outbox rejects every event, to check that mutations roll back with it
+type failingOutboxStore struct {
+	repository.Store
+}
+
+var errOutboxDown = errors.New("outbox unavailable")
+
+func (s failingOutboxStore) Outbox() repository.OutboxRepository { return failingOutbox{} }
+
+func (s failingOutboxStore) Transaction(ctx context.Context, fn func(tx repository.Store) error) error {
+	return s.Store.Transaction(ctx, func(tx repository.Store) error {
+		return fn(failingOutboxStore{tx})
+	})
+}
+
+type failingOutbox struct{}
+
+func (failingOutbox) Enqueue(context.Context, events.Event) error { return errOutboxDown }
+
+func TestOutboxInTransaction(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		eventType string
+		mutate    func(svc UserService, userID uint) error
+	}{
+		{"register", events.TypeUserRegistered, func(svc UserService, _ uint) error {
+			_, err := svc.Register(ctx, "grace@example.com", testPassword)
+			return err
+		}},
+		{"update profile", events.TypeProfileUpdated, func(svc UserService, userID uint) error {
+			_, err := svc.UpdateUserProfile(ctx, userID, 0, "Ada", "Lovelace")
+			return err
+		}},
+		{"add address", events.TypeAddressAdded, func(svc UserService, userID uint) error {
+			_, err := svc.AddUserAddress(ctx, userID, testAddress())
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, store := newTestService(t)
+			userID := mustRegister(t, svc, "ada@example.com")
+			before, err := store.Users().FindByID(ctx, userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outboxBefore, auditBefore := len(store.OutboxEvents()), len(store.AuditLogs())
+
+			// With a failing outbox the change, its audit entry and its event are all discarded
+			svc.store = failingOutboxStore{store}
+			if err := tt.mutate(svc, userID); !errors.Is(err, errOutboxDown) {
+				t.Fatalf("mutation with a failing outbox = %v, want %v", err, errOutboxDown)
+			}
+			after, _ := store.Users().FindByID(ctx, userID)
+			addresses, _ := store.Addresses().ListByUser(ctx, userID)
+			if _, err := store.Users().FindByEmail(ctx, "grace@example.com"); !errors.Is(err, repository.ErrNotFound) ||
+				after.Version != before.Version || len(addresses) != 0 {
+				t.Error("the change was committed without its event")
+			}
+			if len(store.OutboxEvents()) != outboxBefore || len(store.AuditLogs()) != auditBefore {
+				t.Error("events or audit entries were committed without the change")
+			}
+
+			svc.store = store
+			if err := tt.mutate(svc, userID); err != nil {
+				t.Fatal(err)
+			}
+			written := store.OutboxEvents()[outboxBefore:]
+			if len(written) != 1 || written[0].EventType != tt.eventType {
+				t.Errorf("mutation wrote events %+v, want one %s", written, tt.eventType)
+			}
+			if len(store.AuditLogs()) != auditBefore+1 {
+				t.Errorf("mutation wrote %d audit entries, want 1", len(store.AuditLogs())-auditBefore)
+			}
+		})
+	}
+}
+
+func TestDeletePromotesDefault(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		delete      int    // index of the address to delete
+		wantDefault []bool // default flag of the remaining addresses, in insertion order
+	}{
+		// Addresses: 0 shipping (default), 1 shipping, 2 billing (default), 3 shipping
+		{"default is replaced by the newest address of its type", 0, []bool{false, true, true}},
+		{"deleting a non-default keeps the default", 1, []bool{true, true, false}},
+		{"the only address of a type leaves no default behind", 2, []bool{true, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, store := newTestService(t)
+			userID := mustRegister(t, svc, "ada@example.com")
+			var ids []uint
+			for i, addrType := range []string{model.AddressTypeShipping, model.AddressTypeShipping, model.AddressTypeBilling, model.AddressTypeShipping} {
+				addr := testAddress()
+				addr.Type = addrType
+				addr.Street = []string{"1 Main St", "2 Main St", "3 Main St", "4 Main St"}[i]
+				ids = append(ids, mustAddAddress(t, svc, userID, addr).ID)
+			}
+
+			events := len(store.OutboxEvents())
+			if err := svc.DeleteUserAddress(ctx, userID, ids[tt.delete], 0); err != nil {
+				t.Fatal(err)
+			}
+			remaining, err := svc.GetUserAddresses(ctx, userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(remaining) != len(tt.wantDefault) {
+				t.Fatalf("%d addresses remain, want %d", len(remaining), len(tt.wantDefault))
+			}
+			promoted := 0
+			for i, addr := range remaining {
+				if addr.IsDefault != tt.wantDefault[i] {
+					t.Errorf("address %d (%s) default = %v, want %v", addr.ID, addr.Street, addr.IsDefault, tt.wantDefault[i])
+				}
+				if addr.Version > 1 {
+					promoted++
+				}
+			}
+			// One AddressDeleted event, plus one AddressUpdated event for a promoted default
+			if got := len(store.OutboxEvents()) - events; got != 1+promoted {
+				t.Errorf("delete wrote %d events, want %d", got, 1+promoted)
+			}
+		})
+	}
+}
+
+// failingSessionStore is a SessionStore whose revocation reads fail as if Redis were unreachable
+type failingSessionStore struct {
+	repository.SessionStore
+}
+
+var errSessionsDown = errors.New("redis is unavailable")
+
+func (failingSessionStore) RevokedBefore(context.Context, uint) (time.Time, error) {
+	return time.Time{}, errSessionsDown
+}
+
+func TestValidateToken(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		policy  string
+		revoke  *time.Duration // revoke the user's tokens issued before now plus this offset
+		failing bool           // session store is unreachable
+		token   func(token string) string
+		wantErr error
+	}{
+		{"not revoked", config.PolicyFailOpen, nil, false, nil, nil},
+		{"revoked", config.PolicyFailOpen, durationPtr(0), false, nil, auth.ErrTokenRevoked},
+		{"issued after the revocation", config.PolicyFailOpen, durationPtr(-time.Minute), false, nil, nil},
+		{"bad signature is rejected before the session store", config.PolicyFailOpen, nil, true, func(token string) string { return token + "x" }, jwt.ErrTokenSignatureInvalid},
+		{"store down with fail_open", config.PolicyFailOpen, nil, true, nil, nil},
+		{"store down with fail_closed", config.PolicyFailClosed, nil, true, nil, ErrUnavailable},
+		{"revoked with fail_closed", config.PolicyFailClosed, durationPtr(0), false, nil, auth.ErrTokenRevoked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(t)
+			svc.cfg.RevocationRedisPolicy = tt.policy
+			userID := mustRegister(t, svc, "ada@example.com")
+			token, err := svc.Login(ctx, "ada@example.com", testPassword)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.revoke != nil {
+				if err := svc.sessions.RevokeBefore(ctx, userID, time.Now().Add(*tt.revoke), time.Hour); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.failing {
+				svc.sessions = failingSessionStore{svc.sessions}
+			}
+			if tt.token != nil {
+				token = tt.token(token)
+			}
+
+			claims, err := svc.ValidateToken(ctx, token)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ValidateToken() = %v, want nil", err)
+				}
+				if claims.UserID != userID {
+					t.Errorf("claims.UserID = %d, want %d", claims.UserID, userID)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateToken() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration { return &d }
+
+func TestValidateTokenWithoutSession(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t)
+	userID := mustRegister(t, svc, "ada@example.com")
+	first, err := svc.Login(ctx, "ada@example.com", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A lost or overwritten session does not revoke anything: a Redis flush logs nobody out
+	if err := svc.sessions.Delete(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ValidateToken(ctx, first); err != nil {
+		t.Errorf("ValidateToken() without a session = %v, want nil", err)
+	}
+	token, err := auth.GenerateJWT(userID, "ada@example.com", svc.cfg.JWTSecret, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ValidateToken(ctx, token); err != nil {
+		t.Errorf("ValidateToken() of a token issued without storing a session = %v, want nil", err)
+	}
+}
+
+func TestAddUserAddressChecks(t *testing.T) {
+	ctx := context.Background()
+	duplicate := testAddress()
+	duplicate.Street = "1 main street"
+	other := testAddress()
+	other.Street = "9 Elm St"
+
+	tests := []struct {
+		name          string
+		userID        func(registered uint) uint
+		stored        int // addresses added before
+		addr          model.Address
+		wantErr       error
+		wantDuplicate bool
+	}{
+		{"below the limit", nil, 1, other, nil, false},
+		{"at the limit", nil, 2, other, ErrAddressLimitReached, false},
+		{"duplicate is reported before the limit", nil, 2, duplicate, nil, true},
+		{"unknown user", func(registered uint) uint { return registered + 100 }, 0, other, ErrUserNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(t)
+			svc.cfg.MaxAddressesPerUser = 2
+			userID := mustRegister(t, svc, "ada@example.com")
+			for i := 0; i < tt.stored; i++ {
+				addr := testAddress()
+				addr.Street = []string{"1 Main St", "2 Main St"}[i]
+				mustAddAddress(t, svc, userID, addr)
+			}
+			if tt.userID != nil {
+				userID = tt.userID(userID)
+			}
+
+			_, err := svc.AddUserAddress(ctx, userID, tt.addr)
+			var dup *DuplicateAddressError
+			if tt.wantDuplicate {
+				if !errors.As(err, &dup) {
+					t.Errorf("AddUserAddress() = %v, want a DuplicateAddressError", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AddUserAddress() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPatchAddressLabel(t *testing.T) {
+	tests := []struct {
+		name  string
+		label string
+		want  string
+	}{
+		{"label only patch is cleaned up", "  Home   office ", "Home office"},
+		{"blank label clears it", "   ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(t)
+			userID := mustRegister(t, svc, "ada@example.com")
+			addr := mustAddAddress(t, svc, userID, testAddress())
+			patched, err := svc.PatchUserAddress(context.Background(), userID, addr.ID, 0, AddressPatch{Label: &tt.label})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if patched.Label != tt.want {
+				t.Errorf("Label = %q, want %q", patched.Label, tt.want)
+			}
+		})
+	}
+}
+
+// testAddress returns a valid US shipping address
+func testAddress() model.Address {
+	return model.Address{Street: "1 Main St", City: "Springfield", State: "IL", PostalCode: "62701", Country: "US"}
+}
+
+func mustAddAddress(t *testing.T, svc UserService, userID uint, addr model.Address) *model.Address {
+	t.Helper()
+	stored, err := svc.AddUserAddress(context.Background(), userID, addr)
+	if err != nil {
+		t.Fatalf("AddUserAddress(%s) = %v", addr.Street, err)
+	}
+	return stored
+}
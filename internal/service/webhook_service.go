@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/blackwatch66/user-microservice/internal/webhook"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookInput holds the writable fields of a subscription
+type WebhookInput struct {
+	URL         string
+	EventTypes  []string
+	Description string
+	Active      *bool  // nil keeps the current value (true on create)
+	Secret      string // empty generates a secret on create and keeps the current one on update
+}
+
+// WebhookService manages webhook subscriptions and their delivery log
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, input WebhookInput) (*model.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id uint) (*model.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, id uint, input WebhookInput) (*model.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uint) error
+	ListDeliveries(ctx context.Context, subscriptionID uint, status string, limit int) ([]model.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, subscriptionID uint, deliveryID uint64) (*model.WebhookDelivery, []model.WebhookAttempt, error)
+	Redeliver(ctx context.Context, subscriptionID uint, deliveryID uint64) (*model.WebhookDelivery, error)
+}
+
+// webhookServiceImpl implements the WebhookService interface
+type webhookServiceImpl struct {
+	db *gorm.DB
+}
+
+// NewWebhookService creates a new WebhookService instance
+func NewWebhookService(db *gorm.DB) WebhookService {
+	return &webhookServiceImpl{db: db}
+}
+
+// CreateSubscription validates input and stores a new subscription
+func (s *webhookServiceImpl) CreateSubscription(ctx context.Context, input WebhookInput) (*model.WebhookSubscription, error) {
+	if err := validateWebhookInput(input); err != nil {
+		return nil, err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		var err error
+		if secret, err = webhook.NewSecret(); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+	}
+
+	sub := model.WebhookSubscription{
+		URL:         input.URL,
+		EventTypes:  strings.Join(input.EventTypes, ","),
+		Secret:      secret,
+		Description: input.Description,
+		Active:      input.Active == nil || *input.Active,
+	}
+	if err := s.db.WithContext(ctx).Create(&sub).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// ListSubscriptions returns all subscriptions
+func (s *webhookServiceImpl) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	var subs []model.WebhookSubscription
+	if err := s.db.WithContext(ctx).Order("id").Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("database error fetching webhook subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// GetSubscription returns a single subscription
+func (s *webhookServiceImpl) GetSubscription(ctx context.Context, id uint) (*model.WebhookSubscription, error) {
+	var sub model.WebhookSubscription
+	if err := s.db.WithContext(ctx).First(&sub, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("database error fetching webhook subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// UpdateSubscription replaces the URL, event types and description of a subscription
+func (s *webhookServiceImpl) UpdateSubscription(ctx context.Context, id uint, input WebhookInput) (*model.WebhookSubscription, error) {
+	if err := validateWebhookInput(input); err != nil {
+		return nil, err
+	}
+
+	sub, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sub.URL = input.URL
+	sub.EventTypes = strings.Join(input.EventTypes, ",")
+	sub.Description = input.Description
+	if input.Active != nil {
+		sub.Active = *input.Active
+	}
+	if input.Secret != "" {
+		sub.Secret = input.Secret
+	}
+	if err := s.db.WithContext(ctx).Save(sub).Error; err != nil {
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// DeleteSubscription removes a subscription together with its deliveries and attempts
+func (s *webhookServiceImpl) DeleteSubscription(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&model.WebhookSubscription{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete webhook subscription: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrWebhookNotFound
+		}
+		deliveryIDs := tx.Model(&model.WebhookDelivery{}).Select("id").Where("subscription_id = ?", id)
+		if err := tx.Where("delivery_id IN (?)", deliveryIDs).Delete(&model.WebhookAttempt{}).Error; err != nil {
+			return fmt.Errorf("failed to delete webhook attempts: %w", err)
+		}
+		if err := tx.Where("subscription_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListDeliveries returns the most recent deliveries of a subscription, optionally filtered by status
+func (s *webhookServiceImpl) ListDeliveries(ctx context.Context, subscriptionID uint, status string, limit int) ([]model.WebhookDelivery, error) {
+	if _, err := s.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	query := s.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []model.WebhookDelivery
+	if err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("database error fetching webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// GetDelivery returns a delivery and all of its attempts, oldest first
+func (s *webhookServiceImpl) GetDelivery(ctx context.Context, subscriptionID uint, deliveryID uint64) (*model.WebhookDelivery, []model.WebhookAttempt, error) {
+	delivery, err := s.findDelivery(s.db.WithContext(ctx), subscriptionID, deliveryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var attempts []model.WebhookAttempt
+	if err := s.db.WithContext(ctx).Where("delivery_id = ?", deliveryID).Order("id").Find(&attempts).Error; err != nil {
+		return nil, nil, fmt.Errorf("database error fetching webhook attempts: %w", err)
+	}
+	return delivery, attempts, nil
+}
+
+// Redeliver schedules a delivery for immediate sending with a fresh retry budget,
+// regardless of whether it succeeded, is still retrying or is dead
+func (s *webhookServiceImpl) Redeliver(ctx context.Context, subscriptionID uint, deliveryID uint64) (*model.WebhookDelivery, error) {
+	delivery, err := s.findDelivery(s.db.WithContext(ctx), subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Status = model.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeliveredAt = nil
+	if err := s.db.WithContext(ctx).Model(delivery).Select("status", "attempts", "next_attempt_at", "delivered_at").Updates(delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to schedule redelivery: %w", err)
+	}
+	return delivery, nil
+}
+
+func (s *webhookServiceImpl) findDelivery(db *gorm.DB, subscriptionID uint, deliveryID uint64) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := db.Where("id = ? AND subscription_id = ?", deliveryID, subscriptionID).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("database error fetching webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+func validateWebhookInput(input WebhookInput) error {
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &ValidationError{Message: "url must be an absolute http or https URL"}
+	}
+	if err := webhook.ValidateEventTypes(input.EventTypes); err != nil {
+		return &ValidationError{Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/events"
+	"github.com/blackwatch66/user-microservice/internal/logger"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var log = logger.For("webhook")
+
+// Dispatcher is an events.EventPublisher that schedules a delivery for every active subscription
+// matching the event. Deliveries are stored in the database and sent by the Worker, so a slow or
+// failing partner endpoint never blocks the outbox relay.
+type Dispatcher struct {
+	db *gorm.DB
+}
+
+// NewDispatcher creates a new Dispatcher
+func NewDispatcher(db *gorm.DB) *Dispatcher {
+	return &Dispatcher{db: db}
+}
+
+// Publish creates the deliveries for envelope.
+// It is idempotent: an event published again by the relay does not create duplicate deliveries.
+func (d *Dispatcher) Publish(ctx context.Context, envelope *events.Envelope) error {
+	var subscriptions []model.WebhookSubscription
+	if err := d.db.WithContext(ctx).Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+		return fmt.Errorf("failed to load webhook subscriptions: %w", err)
+	}
+
+	var deliveries []model.WebhookDelivery
+	for _, sub := range subscriptions {
+		if !MatchesEventType(sub.EventTypes, envelope.Type) {
+			continue
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        envelope.ID,
+			EventType:      envelope.Type,
+			Status:         model.WebhookDeliveryPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	// Webhook bodies are always JSON, independent of EVENT_ENCODING
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	for i := range deliveries {
+		deliveries[i].Payload = string(payload)
+	}
+
+	if err := d.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to schedule webhook deliveries: %w", err)
+	}
+	log.DebugContext(ctx, "Scheduled webhook deliveries", "event_id", envelope.ID, "event_type", envelope.Type, "count", len(deliveries))
+	return nil
+}
+
+// Close is a no-op
+func (d *Dispatcher) Close() error {
+	return nil
+}
+
+// MatchesEventType reports whether eventType matches one of the comma separated patterns.
+// Patterns use path.Match syntax, e.g. "*" or "user.address_*".
+func MatchesEventType(patterns, eventType string) bool {
+	for _, pattern := range SplitEventTypes(patterns) {
+		if ok, _ := path.Match(pattern, eventType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitEventTypes splits a stored event type filter into its patterns
+func SplitEventTypes(patterns string) []string {
+	var result []string
+	for _, pattern := range strings.Split(patterns, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			result = append(result, pattern)
+		}
+	}
+	return result
+}
+
+// ValidateEventTypes checks that every pattern is well-formed
+func ValidateEventTypes(patterns []string) error {
+	if len(patterns) == 0 {
+		return fmt.Errorf("at least one event type is required")
+	}
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" || strings.Contains(pattern, ",") {
+			return fmt.Errorf("invalid event type pattern %q", pattern)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid event type pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"id":"1","type":"user.registered"}`)
+	now := time.Now()
+	ts := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign(secret, now, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		tolerance time.Duration
+		want      error
+	}{
+		{"valid", secret, signature, ts, body, 5 * time.Minute, nil},
+		{"valid among several signatures", secret, "v0=abc, " + signature, ts, body, 5 * time.Minute, nil},
+		{"other secret", "whsec_other", signature, ts, body, 5 * time.Minute, ErrSignatureMismatch},
+		{"modified body", secret, signature, ts, []byte(`{"id":"2"}`), 5 * time.Minute, ErrSignatureMismatch},
+		{"signature of another timestamp", secret, signature, strconv.FormatInt(now.Unix()-1, 10), body, 5 * time.Minute, ErrSignatureMismatch},
+		{"unknown version", secret, strings.Replace(signature, "v1=", "v2=", 1), ts, body, 5 * time.Minute, ErrSignatureMismatch},
+		{"not hex", secret, "v1=zz", ts, body, 5 * time.Minute, ErrSignatureMismatch},
+		{"empty signature", secret, "", ts, body, 5 * time.Minute, ErrSignatureMismatch},
+		{"too old", secret, Sign(secret, now.Add(-10*time.Minute), body), strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10), body, 5 * time.Minute, ErrTimestampExpired},
+		{"too far in the future", secret, Sign(secret, now.Add(10*time.Minute), body), strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10), body, 5 * time.Minute, ErrTimestampExpired},
+		{"within tolerance", secret, Sign(secret, now.Add(-4*time.Minute), body), strconv.FormatInt(now.Add(-4*time.Minute).Unix(), 10), body, 5 * time.Minute, nil},
+		{"invalid timestamp", secret, signature, "yesterday", body, 5 * time.Minute, ErrTimestampExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.signature, tt.timestamp, tt.body, tt.tolerance)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignFormat(t *testing.T) {
+	signature := Sign("whsec_test", time.Unix(1700000000, 0), []byte("{}"))
+	if !strings.HasPrefix(signature, "v1=") || len(signature) != len("v1=")+64 {
+		t.Errorf("Sign() = %q, want v1= followed by a hex SHA-256 HMAC", signature)
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewSecret()
+	if !strings.HasPrefix(a, "whsec_") || a == b {
+		t.Errorf("NewSecret() = %q, %q, want distinct whsec_ secrets", a, b)
+	}
+}
@@ -0,0 +1,236 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwatch66/user-microservice/internal/metrics"
+	"github.com/blackwatch66/user-microservice/internal/model"
+	"github.com/blackwatch66/user-microservice/internal/outbox"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxResponseBody is the number of response bytes kept in the delivery log
+const maxResponseBody = 4 << 10
+
+// WorkerConfig controls polling, concurrency and retries of the Worker
+type WorkerConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Concurrency  int
+	Timeout      time.Duration // per request
+	MaxAttempts  int           // deliveries are moved to the dead state after this many failed attempts
+	MaxBackoff   time.Duration
+}
+
+// Worker sends pending webhook deliveries
+type Worker struct {
+	db     *gorm.DB
+	client *http.Client
+	cfg    WorkerConfig
+}
+
+// NewWorker creates a new Worker
+func NewWorker(db *gorm.DB, cfg WorkerConfig) *Worker {
+	return &Worker{
+		db: db,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// Redirects are reported as failures instead of being followed
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		cfg: cfg,
+	}
+}
+
+// Run sends due deliveries until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := w.processBatch(ctx)
+			if err != nil {
+				log.ErrorContext(ctx, "Webhook batch failed", "error", err)
+				break
+			}
+			if n < w.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processBatch claims due deliveries and sends them concurrently, it returns the number claimed
+func (w *Worker) processBatch(ctx context.Context) (int, error) {
+	batch, err := w.claim(ctx)
+	if err != nil || len(batch) == 0 {
+		return 0, err
+	}
+
+	subscriptionIDs := make([]uint, 0, len(batch))
+	for _, delivery := range batch {
+		subscriptionIDs = append(subscriptionIDs, delivery.SubscriptionID)
+	}
+	var subscriptions []model.WebhookSubscription
+	if err := w.db.WithContext(ctx).Where("id IN ?", subscriptionIDs).Find(&subscriptions).Error; err != nil {
+		return 0, fmt.Errorf("failed to load webhook subscriptions: %w", err)
+	}
+	byID := make(map[uint]*model.WebhookSubscription, len(subscriptions))
+	for i := range subscriptions {
+		byID[subscriptions[i].ID] = &subscriptions[i]
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(w.cfg.Concurrency, 1))
+	for i := range batch {
+		delivery := &batch[i]
+		g.Go(func() error {
+			w.deliver(gctx, delivery, byID[delivery.SubscriptionID])
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return len(batch), nil
+}
+
+// claim leases up to BatchSize due deliveries by pushing their next attempt past the request timeout.
+// The lease is held without a transaction so that no row locks are kept during HTTP calls;
+// if the process dies mid-delivery the lease expires and another worker picks the delivery up.
+func (w *Worker) claim(ctx context.Context) ([]model.WebhookDelivery, error) {
+	var batch []model.WebhookDelivery
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+			Order("next_attempt_at, id").
+			Limit(w.cfg.BatchSize).
+			Find(&batch).Error; err != nil {
+			return fmt.Errorf("failed to load due deliveries: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		ids := make([]uint64, 0, len(batch))
+		for _, delivery := range batch {
+			ids = append(ids, delivery.ID)
+		}
+		return tx.Model(&model.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(w.cfg.Timeout+30*time.Second)).Error
+	})
+	return batch, err
+}
+
+// deliver sends one delivery and records the attempt
+func (w *Worker) deliver(ctx context.Context, delivery *model.WebhookDelivery, sub *model.WebhookSubscription) {
+	start := time.Now()
+	var statusCode int
+	var responseBody string
+	var sendErr error
+	if sub == nil || !sub.Active {
+		sendErr = fmt.Errorf("subscription is disabled or deleted")
+	} else {
+		statusCode, responseBody, sendErr = w.send(ctx, delivery, sub)
+	}
+	if ctx.Err() != nil {
+		// Shutting down, the lease expires and the delivery is retried later
+		return
+	}
+
+	attempt := model.WebhookAttempt{
+		DeliveryID:   delivery.ID,
+		StatusCode:   statusCode,
+		DurationMs:   time.Since(start).Milliseconds(),
+		ResponseBody: responseBody,
+	}
+	updates := map[string]interface{}{
+		"attempts":         delivery.Attempts + 1,
+		"last_status_code": statusCode,
+	}
+
+	result := model.WebhookDeliverySucceeded
+	switch {
+	case sendErr == nil:
+		now := time.Now()
+		updates["status"] = model.WebhookDeliverySucceeded
+		updates["delivered_at"] = &now
+		updates["last_error"] = ""
+	case delivery.Attempts+1 >= w.cfg.MaxAttempts || sub == nil || !sub.Active:
+		result = model.WebhookDeliveryDead
+		attempt.Error = sendErr.Error()
+		updates["status"] = model.WebhookDeliveryDead
+		updates["last_error"] = sendErr.Error()
+	default:
+		result = "failed"
+		attempt.Error = sendErr.Error()
+		updates["next_attempt_at"] = time.Now().Add(outbox.Backoff(delivery.Attempts+1, w.cfg.MaxBackoff))
+		updates["last_error"] = sendErr.Error()
+	}
+	metrics.WebhookDeliveriesTotal.WithLabelValues(result).Inc()
+	if sendErr != nil {
+		log.WarnContext(ctx, "Webhook delivery failed", "delivery_id", delivery.ID, "subscription_id", delivery.SubscriptionID,
+			"event_type", delivery.EventType, "attempts", delivery.Attempts+1, "status_code", statusCode, "dead", result == model.WebhookDeliveryDead, "error", sendErr)
+	}
+
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(delivery).Updates(updates).Error
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to record webhook attempt", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// send performs the signed HTTP request, any non-2xx response is an error
+func (w *Worker) send(ctx context.Context, delivery *model.WebhookDelivery, sub *model.WebhookSubscription) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "user-service-webhooks/1.0")
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderDeliveryID, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, now, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, storableText(respBody), fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, storableText(respBody), nil
+}
+
+// storableText converts a response body to text every database accepts: the body may be binary or cut
+// in the middle of a character, and PostgreSQL rejects invalid UTF-8 and NUL bytes in text columns
+func storableText(body []byte) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(string(body), "\uFFFD"), "\x00", "\uFFFD")
+}
@@ -0,0 +1,23 @@
+package webhook
+
+import "testing"
+
+func TestStorableText(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{"text", []byte("ok"), "ok"},
+		{"multi-byte", []byte("好"), "好"},
+		{"cut character", []byte("好")[:2], "�"},
+		{"binary", []byte{0xff, 'a', 0x00, 'b'}, "�a�b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := storableText(tt.body); got != tt.want {
+				t.Errorf("storableText(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}